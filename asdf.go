@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"timberlea-upload-tool/installer"
+)
+
+/*
+runAsdf implements the asdf subcommands, which follow the asdf plugin
+contract (also used by mise) so that a plugin's bin/ scripts can simply
+call them:
+  - list-all prints every stable version, oldest first, on one line
+  - latest-stable [QUERY] prints the newest stable version starting with QUERY
+  - download fetches ASDF_INSTALL_VERSION into ASDF_DOWNLOAD_PATH
+  - install installs ASDF_INSTALL_VERSION into ASDF_INSTALL_PATH/bin, using
+    the asset in ASDF_DOWNLOAD_PATH if download already fetched it
+
+Versions are printed without the leading "v", as asdf expects. Progress and
+errors go to stderr; settings come from the configuration layers as for
+install, and PATH is never modified since asdf manages it.
+
+Parameters:
+  - args: Arguments after `asdf`
+
+Returns:
+  - int: The process exit status
+*/
+func runAsdf(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: ollama-installer asdf list-all|latest-stable [QUERY]|download|install")
+		return exitUsage
+	}
+
+	opts, cli, err := parseInstallArgs(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	opts.Observer = NewConsoleObserver(os.Stderr, cli.quiet)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+
+	switch args[0] {
+	case "list-all":
+		return asdfListAll(ctx, opts)
+	case "latest-stable":
+		query := ""
+		if len(args) > 1 {
+			query = args[1]
+		}
+		return asdfLatestStable(ctx, opts, query)
+	case "download":
+		return asdfDownload(ctx, opts)
+	case "install":
+		return asdfInstall(ctx, opts)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown asdf command %q\n", args[0])
+		return exitUsage
+	}
+}
+
+/*
+asdfListAll prints every stable version, oldest first, space-separated.
+
+Parameters:
+  - ctx: Context for cancellation
+  - opts: Installer options
+
+Returns:
+  - int: The process exit status
+*/
+func asdfListAll(ctx context.Context, opts installer.Options) int {
+	versions, err := stableVersions(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list versions: %v\n", err)
+		return exitCode(err)
+	}
+
+	fmt.Println(strings.Join(versions, " "))
+	return exitOK
+}
+
+/*
+asdfLatestStable prints the newest stable version starting with query.
+
+Parameters:
+  - ctx: Context for cancellation
+  - opts: Installer options
+  - query: Version prefix to match (empty matches every version)
+
+Returns:
+  - int: The process exit status
+*/
+func asdfLatestStable(ctx context.Context, opts installer.Options, query string) int {
+	versions, err := stableVersions(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list versions: %v\n", err)
+		return exitCode(err)
+	}
+
+	query = strings.TrimPrefix(query, "v")
+	for n := len(versions) - 1; n >= 0; n-- {
+		if strings.HasPrefix(versions[n], query) {
+			fmt.Println(versions[n])
+			return exitOK
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "No stable version matches %q\n", query)
+	return exitAssetNotFound
+}
+
+/*
+stableVersions lists the versions of all stable releases, oldest first,
+without the leading "v".
+
+Parameters:
+  - ctx: Context for cancellation
+  - opts: Installer options
+
+Returns:
+  - []string: The versions
+  - error: Any error listing the releases
+*/
+func stableVersions(ctx context.Context, opts installer.Options) ([]string, error) {
+	inst, err := installer.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := inst.Releases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, release := range releases {
+		if !release.Prerelease {
+			versions = append(versions, strings.TrimPrefix(release.TagName, "v"))
+		}
+	}
+	return versions, nil
+}
+
+/*
+asdfDownload downloads and verifies the release asset of
+ASDF_INSTALL_VERSION into ASDF_DOWNLOAD_PATH.
+
+Parameters:
+  - ctx: Context for cancellation
+  - opts: Installer options
+
+Returns:
+  - int: The process exit status
+*/
+func asdfDownload(ctx context.Context, opts installer.Options) int {
+	version, err := asdfVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	dir := os.Getenv("ASDF_DOWNLOAD_PATH")
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "ASDF_DOWNLOAD_PATH is not set")
+		return exitUsage
+	}
+	opts.Version = version
+
+	inst, err := installer.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitCode(err)
+	}
+
+	path, err := inst.Fetch(ctx, dir)
+	if err != nil {
+		return exitCode(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Downloaded Ollama %s to %s\n", version, path)
+	return exitOK
+}
+
+/*
+asdfInstall installs ASDF_INSTALL_VERSION into ASDF_INSTALL_PATH/bin
+without touching PATH, reusing the asset in ASDF_DOWNLOAD_PATH if present.
+
+Parameters:
+  - ctx: Context for cancellation
+  - opts: Installer options
+
+Returns:
+  - int: The process exit status
+*/
+func asdfInstall(ctx context.Context, opts installer.Options) int {
+	if installType := os.Getenv("ASDF_INSTALL_TYPE"); installType != "" && installType != "version" {
+		fmt.Fprintf(os.Stderr, "install type %q is not supported; only released versions can be installed\n", installType)
+		return exitUsage
+	}
+	version, err := asdfVersion()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	installPath := os.Getenv("ASDF_INSTALL_PATH")
+	if installPath == "" {
+		fmt.Fprintln(os.Stderr, "ASDF_INSTALL_PATH is not set")
+		return exitUsage
+	}
+
+	opts.Version = version
+	opts.InstallDir = filepath.Join(installPath, "bin")
+	opts.PathUpdater = asdfPathUpdater{}
+
+	if dir := os.Getenv("ASDF_DOWNLOAD_PATH"); dir != "" {
+		archive := filepath.Join(dir, installer.AssetName())
+		if _, err := os.Stat(archive); err == nil {
+			opts.Archive = archive
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitFailure
+		}
+	}
+
+	inst, err := installer.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitCode(err)
+	}
+
+	_, err = inst.Install(ctx)
+	return exitCode(err)
+}
+
+/*
+asdfVersion returns the release tag named by ASDF_INSTALL_VERSION.
+
+Returns:
+  - string: The release tag (e.g., "v0.5.7")
+  - error: An error if the variable is not set or not a release tag
+*/
+func asdfVersion() (string, error) {
+	version := os.Getenv("ASDF_INSTALL_VERSION")
+	if version == "" {
+		return "", errors.New("ASDF_INSTALL_VERSION is not set")
+	}
+	version = installer.NormalizeVersion(version)
+	if err := installer.ValidateVersion(version); err != nil {
+		return "", fmt.Errorf("invalid ASDF_INSTALL_VERSION: %w", err)
+	}
+	return version, nil
+}
+
+/*
+asdfPathUpdater is the PathUpdater for asdf installations. It changes
+nothing, since asdf puts the version's bin directory on PATH through its
+own shims.
+*/
+type asdfPathUpdater struct{}
+
+/*
+UpdatePath implements installer.PathUpdater without touching PATH.
+
+Parameters:
+  - ctx: Context for cancellation (unused)
+  - binDir: The directory holding the binary (unused)
+
+Returns:
+  - error: Always nil
+*/
+func (asdfPathUpdater) UpdatePath(ctx context.Context, binDir string) error {
+	return nil
+}
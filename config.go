@@ -0,0 +1,485 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"timberlea-upload-tool/installer"
+)
+
+const (
+	/* Directory and file name of the configuration file in each layer */
+	configDirName  = "ollama-installer"
+	configFileName = "config.json"
+
+	/* Prefix of environment variables that override configuration files */
+	configEnvPrefix = "OLLAMA_INSTALLER_"
+
+	/* The user configuration may hold a token, so keep it private */
+	userConfigMode = 0600
+)
+
+/* Directory holding the system-wide configuration on Unix (replaced by tests) */
+var systemConfigDir = "/etc"
+
+/*
+Install flags that can be given defaults in configuration files and
+environment variables, in the order `config list` shows them.
+*/
+var configKeys = []string{
+	"mirror",
+	"trust-mirror-checksums",
+	"connections",
+	"limit-rate",
+	"ollama-version",
+	"install-dir",
+	"token",
+	"serve-check",
+	"quiet",
+	"output",
+	"json-events",
+	"shim",
+	"auto-install",
+	"env",
+	"no-modify-path",
+	"profile",
+	"no-ci",
+	"export-file",
+}
+
+/* Settings that hold a list of values rather than a single one */
+var configListKeys = []string{"mirror", "env"}
+
+/*
+configValue is a setting taken from one configuration layer. List settings
+(such as mirror) may have several values.
+*/
+type configValue struct {
+	/* values are the setting's values in flag syntax */
+	values []string
+
+	/* source names the file or environment variable the values came from */
+	source string
+}
+
+/*
+config is the merged configuration, keyed by flag name. Layers are merged
+in increasing order of precedence: the system file, the user file and then
+environment variables, so a later layer replaces the whole setting from an
+earlier one. Command-line flags are applied on top by the caller.
+*/
+type config map[string]configValue
+
+/*
+systemConfigPath returns the location of the system-wide configuration file.
+
+Returns:
+  - string: The file path, or "" if there is no system location
+*/
+func systemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			return ""
+		}
+		return filepath.Join(programData, configDirName, configFileName)
+	}
+	return filepath.Join(systemConfigDir, configDirName, configFileName)
+}
+
+/*
+userConfigPath returns the location of the user's configuration file,
+following the XDG base directory specification.
+
+Returns:
+  - string: The file path
+  - error: An error if no configuration directory can be determined
+*/
+func userConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			var err error
+			if dir, err = os.UserConfigDir(); err != nil {
+				return "", fmt.Errorf("failed to get config directory: %w", err)
+			}
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to get home directory: %w", err)
+			}
+			dir = filepath.Join(home, ".config")
+		}
+	}
+	return filepath.Join(dir, configDirName, configFileName), nil
+}
+
+/*
+configEnvName returns the environment variable that sets a configuration key.
+
+Parameters:
+  - key: The configuration key (flag name)
+
+Returns:
+  - string: The variable name (e.g., OLLAMA_INSTALLER_LIMIT_RATE)
+*/
+func configEnvName(key string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+/*
+loadConfig reads every configuration layer and merges them.
+
+Returns:
+  - config: The merged configuration
+  - error: Any error reading or parsing a configuration file
+*/
+func loadConfig() (config, error) {
+	cfg := config{}
+
+	userPath, err := userConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range []string{systemConfigPath(), userPath} {
+		if path == "" {
+			continue
+		}
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, list := range values {
+			cfg[key] = configValue{values: list, source: path}
+		}
+	}
+
+	for _, key := range configKeys {
+		name := configEnvName(key)
+		value := os.Getenv(name)
+		if value == "" && key == "token" {
+			name, value = "GITHUB_TOKEN", os.Getenv("GITHUB_TOKEN")
+		}
+		if value == "" {
+			continue
+		}
+
+		list := []string{value}
+		if slices.Contains(configListKeys, key) {
+			list = strings.Split(value, ",")
+			for i := range list {
+				list[i] = strings.TrimSpace(list[i])
+			}
+		}
+		cfg[key] = configValue{values: list, source: "$" + name}
+	}
+
+	return cfg, nil
+}
+
+/*
+readConfigFile reads one configuration file. A missing file is not an
+error and yields no settings.
+
+Parameters:
+  - path: Path of the JSON configuration file
+
+Returns:
+  - map[string][]string: The settings in the file, in flag syntax
+  - error: Any error reading the file, or an unknown key or invalid value
+*/
+func readConfigFile(path string) (map[string][]string, error) {
+	raw, err := readConfigJSON(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]string, len(raw))
+	for key, value := range raw {
+		if !slices.Contains(configKeys, key) {
+			return nil, fmt.Errorf("%s: unknown setting %q", path, key)
+		}
+		list, err := configStrings(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		values[key] = list
+	}
+	return values, nil
+}
+
+/*
+readConfigJSON reads a configuration file as a raw JSON object.
+
+Parameters:
+  - path: Path of the JSON configuration file
+
+Returns:
+  - map[string]any: The decoded object (empty if the file does not exist)
+  - error: Any error reading or decoding the file
+*/
+func readConfigJSON(path string) (map[string]any, error) {
+	raw := map[string]any{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return raw, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return raw, nil
+}
+
+/*
+configStrings converts a JSON value to flag syntax. Strings, numbers and
+booleans give one value; arrays of those give one value per element.
+
+Parameters:
+  - value: The decoded JSON value
+
+Returns:
+  - []string: The values in flag syntax
+  - error: An error for objects, nested arrays and null
+*/
+func configStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		var list []string
+		for _, item := range v {
+			if _, nested := item.([]any); nested {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			values, err := configStrings(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, values...)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
+
+/*
+apply sets every configured value on the install flags, as if it had been
+given on the command line before the real arguments.
+
+Parameters:
+  - flags: The install command's flag set
+
+Returns:
+  - error: An error naming the source of any invalid value
+*/
+func (c config) apply(flags *flag.FlagSet) error {
+	for _, key := range configKeys {
+		setting, ok := c[key]
+		if !ok {
+			continue
+		}
+		for _, value := range setting.values {
+			if err := flags.Set(key, value); err != nil {
+				return fmt.Errorf("invalid value %q for %s in %s: %w", value, key, setting.source, err)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+runConfig implements the `config` subcommand:
+
+	config list               show every setting, its value and where it comes from
+	config get KEY            print the effective value of a setting
+	config set KEY [VALUE...] store a setting in the user configuration file
+	                          (with no value, remove it)
+
+Parameters:
+  - args: Arguments after `config`
+
+Returns:
+  - int: The process exit status
+*/
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: config list | config get KEY | config set KEY [VALUE...]")
+		return exitUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitFailure
+	}
+
+	switch command, rest := args[0], args[1:]; {
+	case command == "list" && len(rest) == 0:
+		listConfig(os.Stdout, cfg)
+		return exitOK
+	case command == "get" && len(rest) == 1:
+		values, err := getConfig(cfg, rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitUsage
+		}
+		for _, value := range values {
+			fmt.Println(value)
+		}
+		return exitOK
+	case command == "set" && len(rest) >= 1:
+		path, err := setConfig(rest[0], rest[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return exitUsage
+		}
+		if len(rest) == 1 {
+			fmt.Printf("Removed %s from %s\n", rest[0], path)
+		} else {
+			fmt.Printf("Set %s in %s\n", rest[0], path)
+		}
+		return exitOK
+	}
+
+	fmt.Fprintln(os.Stderr, "usage: config list | config get KEY | config set KEY [VALUE...]")
+	return exitUsage
+}
+
+/*
+listConfig writes every configuration key with its effective value and
+the layer it comes from. Tokens are masked.
+
+Parameters:
+  - w: Destination for the listing
+  - cfg: The merged configuration
+*/
+func listConfig(w io.Writer, cfg config) {
+	defaults := installFlags(&installer.Options{}, &cliOptions{})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, key := range configKeys {
+		value, source := defaults.Lookup(key).DefValue, "default"
+		if setting, ok := cfg[key]; ok {
+			value, source = strings.Join(setting.values, ","), setting.source
+		}
+		if key == "token" && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, value, source)
+	}
+	tw.Flush()
+}
+
+/*
+getConfig returns the effective value of a configuration key, falling
+back to the flag default when no layer sets it.
+
+Parameters:
+  - cfg: The merged configuration
+  - key: The configuration key
+
+Returns:
+  - []string: The values (one per line for list settings)
+  - error: An error if key is not a configuration key
+*/
+func getConfig(cfg config, key string) ([]string, error) {
+	if !slices.Contains(configKeys, key) {
+		return nil, fmt.Errorf("unknown setting %q (valid settings: %s)", key, strings.Join(configKeys, ", "))
+	}
+	if setting, ok := cfg[key]; ok {
+		return setting.values, nil
+	}
+
+	defaults := installFlags(&installer.Options{}, &cliOptions{})
+	return []string{defaults.Lookup(key).DefValue}, nil
+}
+
+/*
+setConfig validates values for a key and stores them in the user
+configuration file, keeping the file's other settings. Booleans and
+numbers are stored as JSON booleans and numbers.
+
+Parameters:
+  - key: The configuration key
+  - values: The new values; empty removes the key
+
+Returns:
+  - string: Path of the user configuration file
+  - error: An error for unknown keys, invalid values or file failures
+*/
+func setConfig(key string, values []string) (string, error) {
+	if !slices.Contains(configKeys, key) {
+		return "", fmt.Errorf("unknown setting %q (valid settings: %s)", key, strings.Join(configKeys, ", "))
+	}
+	isList := slices.Contains(configListKeys, key)
+	if len(values) > 1 && !isList {
+		return "", fmt.Errorf("%s takes a single value", key)
+	}
+
+	/* Validate by parsing the values exactly as flags would be */
+	flags := installFlags(&installer.Options{}, &cliOptions{})
+	for _, value := range values {
+		if err := flags.Set(key, value); err != nil {
+			return "", fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+		}
+	}
+
+	path, err := userConfigPath()
+	if err != nil {
+		return "", err
+	}
+	raw, err := readConfigJSON(path)
+	if err != nil {
+		return "", err
+	}
+
+	f := flags.Lookup(key)
+	boolFlag, _ := f.Value.(interface{ IsBoolFlag() bool })
+	_, numeric := strconv.Atoi(f.DefValue)
+
+	switch {
+	case len(values) == 0:
+		delete(raw, key)
+	case isList:
+		raw[key] = values
+	case boolFlag != nil && boolFlag.IsBoolFlag():
+		raw[key], _ = strconv.ParseBool(values[0])
+	case numeric == nil:
+		raw[key], _ = strconv.Atoi(values[0])
+	default:
+		raw[key] = values[0]
+	}
+
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), userConfigMode); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
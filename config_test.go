@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+/*
+testConfig isolates the configuration layers: the system and user files
+live in temporary directories and no OLLAMA_INSTALLER_* variable is set.
+It returns the paths of the system and user files.
+*/
+func testConfig(t *testing.T) (string, string) {
+	t.Helper()
+
+	systemDir := t.TempDir()
+	previous := systemConfigDir
+	systemConfigDir = systemDir
+	t.Cleanup(func() { systemConfigDir = previous })
+	t.Setenv("ProgramData", systemDir)
+
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv("GITHUB_TOKEN", "")
+	for _, key := range configKeys {
+		t.Setenv(configEnvName(key), "")
+	}
+
+	return filepath.Join(systemDir, configDirName, configFileName), filepath.Join(userDir, configDirName, configFileName)
+}
+
+func writeTestConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if content == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseInstallArgsLayers(t *testing.T) {
+	type settings struct {
+		connections int
+		limitRate   int64
+		mirrors     []string
+		env         map[string]string
+		token       string
+		quiet       bool
+	}
+	defaults := settings{connections: 4}
+
+	tests := []struct {
+		name    string
+		system  string
+		user    string
+		environ map[string]string
+		args    []string
+		want    settings
+	}{
+		{
+			name: "defaults",
+			want: defaults,
+		},
+		{
+			name:   "system file",
+			system: `{"connections": 2, "limit-rate": "5M"}`,
+			want:   settings{connections: 2, limitRate: 5 << 20},
+		},
+		{
+			name:   "user file over system file",
+			system: `{"connections": 2, "limit-rate": "5M"}`,
+			user:   `{"connections": 3}`,
+			want:   settings{connections: 3, limitRate: 5 << 20},
+		},
+		{
+			name:    "environment over user file",
+			user:    `{"connections": 3, "quiet": true}`,
+			environ: map[string]string{"OLLAMA_INSTALLER_CONNECTIONS": "5"},
+			want:    settings{connections: 5, quiet: true},
+		},
+		{
+			name:    "flags over environment",
+			user:    `{"quiet": true}`,
+			environ: map[string]string{"OLLAMA_INSTALLER_CONNECTIONS": "5"},
+			args:    []string{"--connections", "6", "--quiet=false"},
+			want:    settings{connections: 6},
+		},
+		{
+			name:   "user list replaces system list",
+			system: `{"mirror": ["https://a.example.com", "https://b.example.com"]}`,
+			user:   `{"mirror": ["https://c.example.com/"]}`,
+			want:   settings{connections: 4, mirrors: []string{"https://c.example.com"}},
+		},
+		{
+			name:    "environment list is comma-separated",
+			user:    `{"mirror": "https://c.example.com"}`,
+			environ: map[string]string{"OLLAMA_INSTALLER_MIRROR": "https://a.example.com, github"},
+			want:    settings{connections: 4, mirrors: []string{"https://a.example.com", "https://github.com/ollama/ollama/releases/download"}},
+		},
+		{
+			name: "flags replace configured list",
+			user: `{"mirror": ["https://c.example.com"]}`,
+			args: []string{"--mirror", "https://d.example.com", "--mirror", "https://e.example.com"},
+			want: settings{connections: 4, mirrors: []string{"https://d.example.com", "https://e.example.com"}},
+		},
+		{
+			name:    "environment env settings replace the file's",
+			user:    `{"env": ["OLLAMA_HOST=0.0.0.0:11434", "OLLAMA_KEEP_ALIVE=1h"]}`,
+			environ: map[string]string{"OLLAMA_INSTALLER_ENV": "OLLAMA_HOST=127.0.0.1:8080"},
+			want:    settings{connections: 4, env: map[string]string{"OLLAMA_HOST": "127.0.0.1:8080"}},
+		},
+		{
+			name:    "GITHUB_TOKEN",
+			environ: map[string]string{"GITHUB_TOKEN": "from-github"},
+			want:    settings{connections: 4, token: "from-github"},
+		},
+		{
+			name:    "OLLAMA_INSTALLER_TOKEN over GITHUB_TOKEN",
+			environ: map[string]string{"GITHUB_TOKEN": "from-github", "OLLAMA_INSTALLER_TOKEN": "from-installer"},
+			want:    settings{connections: 4, token: "from-installer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			systemPath, userPath := testConfig(t)
+			writeTestConfig(t, systemPath, tt.system)
+			writeTestConfig(t, userPath, tt.user)
+			for name, value := range tt.environ {
+				t.Setenv(name, value)
+			}
+
+			opts, cli, err := parseInstallArgs(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := settings{
+				connections: opts.Connections,
+				limitRate:   opts.LimitRate,
+				mirrors:     opts.Mirrors,
+				env:         opts.Env,
+				token:       opts.Token,
+				quiet:       cli.quiet,
+			}
+			if got.connections != tt.want.connections || got.limitRate != tt.want.limitRate ||
+				!slices.Equal(got.mirrors, tt.want.mirrors) || !maps.Equal(got.env, tt.want.env) ||
+				got.token != tt.want.token || got.quiet != tt.want.quiet {
+				t.Errorf("parseInstallArgs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    string
+		environ map[string]string
+	}{
+		{name: "unknown setting", user: `{"colour": true}`},
+		{name: "malformed file", user: `{"quiet": `},
+		{name: "object value", user: `{"mirror": {"url": "https://a.example.com"}}`},
+		{name: "invalid value in file", user: `{"connections": "many"}`},
+		{name: "invalid value in environment", environ: map[string]string{"OLLAMA_INSTALLER_LIMIT_RATE": "fast"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, userPath := testConfig(t)
+			writeTestConfig(t, userPath, tt.user)
+			for name, value := range tt.environ {
+				t.Setenv(name, value)
+			}
+
+			if _, _, err := parseInstallArgs(nil); err == nil {
+				t.Error("parseInstallArgs() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		values  []string
+		want    any
+		wantErr bool
+	}{
+		{name: "bool", key: "serve-check", values: []string{"true"}, want: true},
+		{name: "bool false", key: "quiet", values: []string{"false"}, want: false},
+		{name: "integer", key: "connections", values: []string{"8"}, want: float64(8)},
+		{name: "byte size", key: "limit-rate", values: []string{"5M"}, want: "5M"},
+		{name: "string", key: "ollama-version", values: []string{"0.5.7"}, want: "0.5.7"},
+		{name: "list", key: "mirror", values: []string{"https://a.example.com", "github"}, want: []any{"https://a.example.com", "github"}},
+		{name: "invalid bool", key: "quiet", values: []string{"maybe"}, wantErr: true},
+		{name: "invalid integer", key: "connections", values: []string{"many"}, wantErr: true},
+		{name: "invalid byte size", key: "limit-rate", values: []string{"fast"}, wantErr: true},
+		{name: "several values for a single setting", key: "connections", values: []string{"1", "2"}, wantErr: true},
+		{name: "unknown setting", key: "colour", values: []string{"red"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, userPath := testConfig(t)
+			writeTestConfig(t, userPath, `{"token": "kept"}`)
+
+			path, err := setConfig(tt.key, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if path != userPath {
+				t.Errorf("setConfig() wrote %s, want %s", path, userPath)
+			}
+
+			raw := readTestConfig(t, userPath)
+			if got, _ := json.Marshal(raw[tt.key]); string(got) != mustMarshal(t, tt.want) {
+				t.Errorf("%s = %s, want %s", tt.key, got, mustMarshal(t, tt.want))
+			}
+			if raw["token"] != "kept" {
+				t.Errorf("token = %v, want the existing setting kept", raw["token"])
+			}
+
+			/* No value removes the setting again */
+			if _, err := setConfig(tt.key, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := readTestConfig(t, userPath)[tt.key]; ok {
+				t.Errorf("%s still set after removing it", tt.key)
+			}
+		})
+	}
+}
+
+func readTestConfig(t *testing.T, path string) map[string]any {
+	t.Helper()
+	raw, err := readConfigJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
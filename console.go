@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"timberlea-upload-tool/installer"
+)
+
+const (
+	/* Percentage step between plain-text progress messages */
+	progressMilestoneStep = 10
+
+	/* Interval between plain-text messages when the total size is unknown */
+	progressUnknownSizeInterval = 10 * time.Second
+
+	/* Width of the interactive progress bar in characters */
+	progressBarWidth = 30
+)
+
+/*
+progressMode selects how download progress is displayed.
+*/
+type progressMode int
+
+const (
+	/* progressBar redraws a single line with a bar, speed and ETA */
+	progressBar progressMode = iota
+
+	/* progressMilestones prints a plain line at every 10% */
+	progressMilestones
+
+	/* progressQuiet prints nothing */
+	progressQuiet
+)
+
+/*
+detectProgressMode chooses the progress display for the current process.
+Interactive terminals get a redrawn progress bar, while redirected output
+(such as CI logs) gets occasional plain-text lines.
+
+Parameters:
+  - out: The file progress is written to
+  - quiet: Whether progress output was disabled with --quiet
+
+Returns:
+  - progressMode: The display mode to use
+*/
+func detectProgressMode(out *os.File, quiet bool) progressMode {
+	if quiet {
+		return progressQuiet
+	}
+	if isTerminal(out) {
+		return progressBar
+	}
+	return progressMilestones
+}
+
+/*
+isTerminal reports whether the file is an interactive terminal.
+*/
+func isTerminal(file *os.File) bool {
+	stat, err := file.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+/*
+ConsoleObserver prints installation events as human-readable text on
+stdout. Download progress is drawn according to its progressMode.
+*/
+type ConsoleObserver struct {
+	out           *os.File
+	mode          progressMode
+	nextMilestone int64
+	lastLine      time.Duration
+	barActive     bool
+	mu            sync.Mutex
+}
+
+/*
+NewConsoleObserver creates an observer that writes to a console stream.
+
+Parameters:
+  - out: The stream to write to (normally stdout)
+  - quiet: Whether download progress should be hidden
+
+Returns:
+  - *ConsoleObserver: The console observer
+*/
+func NewConsoleObserver(out *os.File, quiet bool) *ConsoleObserver {
+	return &ConsoleObserver{out: out, mode: detectProgressMode(out, quiet)}
+}
+
+/*
+OnEvent implements installer.Observer by printing the event.
+*/
+func (c *ConsoleObserver) OnEvent(e installer.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Kind {
+	case installer.EventDownloadProgress:
+		c.renderProgress(e)
+		return
+	case installer.EventDownloadFinished:
+		c.finishProgress(e)
+		return
+	case installer.EventDownloadStarted:
+		c.nextMilestone = progressMilestoneStep
+		c.lastLine = 0
+	}
+
+	/* Move off an unfinished progress bar before printing a message */
+	if c.barActive {
+		fmt.Fprintln(c.out)
+		c.barActive = false
+	}
+
+	switch e.Kind {
+	case installer.EventWarning:
+		fmt.Fprintf(c.out, "Warning: %s\n", e.Message)
+	case installer.EventFailed:
+		fmt.Fprintf(c.out, "%s\n", e.Message)
+	default:
+		if e.Message != "" {
+			fmt.Fprintf(c.out, "%s\n", e.Message)
+		}
+	}
+
+	/* Show edits to shell files to people watching, not to logs */
+	if e.Diff != "" && isTerminal(c.out) {
+		fmt.Fprint(c.out, e.Diff)
+	}
+}
+
+/*
+renderProgress updates the progress display for a download progress event.
+*/
+func (c *ConsoleObserver) renderProgress(e installer.Event) {
+	switch c.mode {
+	case progressBar:
+		c.renderBar(e)
+	case progressMilestones:
+		if e.BytesTotal > 0 {
+			percent := e.BytesDone * 100 / e.BytesTotal
+			if percent >= c.nextMilestone && percent < 100 {
+				c.renderLine(e)
+				c.nextMilestone = (percent/progressMilestoneStep + 1) * progressMilestoneStep
+			}
+		} else if e.Elapsed-c.lastLine >= progressUnknownSizeInterval {
+			c.lastLine = e.Elapsed
+			c.renderLine(e)
+		}
+	}
+}
+
+/*
+finishProgress draws the final state of a download and ends the bar.
+*/
+func (c *ConsoleObserver) finishProgress(e installer.Event) {
+	switch c.mode {
+	case progressBar:
+		c.renderBar(e)
+		fmt.Fprintln(c.out)
+		c.barActive = false
+	case progressMilestones:
+		c.renderLine(e)
+	}
+}
+
+/*
+renderBar redraws the interactive progress line in place.
+*/
+func (c *ConsoleObserver) renderBar(e installer.Event) {
+	speed := bytesPerSecond(e)
+
+	var line string
+	if e.BytesTotal > 0 {
+		fraction := min(float64(e.BytesDone)/float64(e.BytesTotal), 1)
+		filled := int(fraction * progressBarWidth)
+		bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+		if filled > 0 && filled < progressBarWidth {
+			bar = bar[:filled-1] + ">" + bar[filled:]
+		}
+		line = fmt.Sprintf("[%s] %5.1f%% %s/%s %s/s ETA %s",
+			bar, fraction*100, formatBytes(e.BytesDone), formatBytes(e.BytesTotal), formatBytes(int64(speed)), eta(e, speed))
+	} else {
+		line = fmt.Sprintf("Downloaded %s %s/s", formatBytes(e.BytesDone), formatBytes(int64(speed)))
+	}
+
+	/* Pad to clear leftovers from a longer previous line */
+	fmt.Fprintf(c.out, "\r%-80s", line)
+	c.barActive = true
+}
+
+/*
+renderLine prints a plain-text progress message on its own line.
+*/
+func (c *ConsoleObserver) renderLine(e installer.Event) {
+	speed := formatBytes(int64(bytesPerSecond(e)))
+
+	if e.BytesTotal > 0 {
+		fmt.Fprintf(c.out, "Downloaded %d%% (%s of %s, %s/s)\n",
+			e.BytesDone*100/e.BytesTotal, formatBytes(e.BytesDone), formatBytes(e.BytesTotal), speed)
+	} else {
+		fmt.Fprintf(c.out, "Downloaded %s (%s/s)\n", formatBytes(e.BytesDone), speed)
+	}
+}
+
+/*
+bytesPerSecond returns the average throughput of a download progress event.
+*/
+func bytesPerSecond(e installer.Event) float64 {
+	if e.Elapsed <= 0 {
+		return 0
+	}
+	return float64(e.BytesDone) / e.Elapsed.Seconds()
+}
+
+/*
+eta formats the estimated time remaining for a download at the given speed.
+*/
+func eta(e installer.Event, speed float64) string {
+	if speed <= 0 || e.BytesDone >= e.BytesTotal {
+		return "--:--"
+	}
+
+	remaining := time.Duration(float64(e.BytesTotal-e.BytesDone) / speed * float64(time.Second))
+	remaining = remaining.Round(time.Second)
+	if remaining >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(remaining.Hours()), int(remaining.Minutes())%60, int(remaining.Seconds())%60)
+	}
+	return fmt.Sprintf("%02d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60)
+}
+
+/*
+formatBytes formats a byte count using binary units (e.g., "1.5 GiB").
+
+Parameters:
+  - n: Number of bytes
+
+Returns:
+  - string: Human-readable size
+*/
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+/*
+printPlan writes a dry-run installation plan in human-readable form.
+
+Parameters:
+  - w: Destination for the plan (normally stdout)
+  - plan: The plan returned by installer.Plan
+*/
+func printPlan(w io.Writer, plan installer.Plan) {
+	size := "unknown"
+	if plan.Size >= 0 {
+		size = formatBytes(plan.Size)
+	}
+
+	fmt.Fprintln(w, "Dry run: no files will be downloaded or changed")
+	fmt.Fprintf(w, "  Version:       %s\n", plan.Version)
+	fmt.Fprintf(w, "  Download:      %s (%s)\n", plan.URL, size)
+	fmt.Fprintf(w, "  SHA-256:       %s\n", plan.Checksum)
+	if plan.Overwrite {
+		fmt.Fprintf(w, "  Install path:  %s (replaces the existing binary)\n", plan.InstallPath)
+	} else {
+		fmt.Fprintf(w, "  Install path:  %s\n", plan.InstallPath)
+	}
+
+	switch change := plan.PathChange; {
+	case change == nil:
+		fmt.Fprintln(w, "  PATH update:   none")
+	case change.Present && change.File == "":
+		fmt.Fprintln(w, "  PATH update:   none, new shells already have it on PATH")
+	case change.Present:
+		fmt.Fprintf(w, "  PATH update:   none, %s already adds the directory to PATH\n", change.File)
+	case change.Manual && change.File == "":
+		fmt.Fprintf(w, "  PATH update:   none, run in later commands:\n                   %s\n", change.Line)
+	case change.Manual:
+		fmt.Fprintf(w, "  PATH update:   none, add these lines to %s yourself:\n", change.File)
+		for _, line := range strings.Split(strings.TrimSuffix(change.Line, "\n"), "\n") {
+			fmt.Fprintf(w, "                   %s\n", line)
+		}
+	case change.Update:
+		fmt.Fprintf(w, "  PATH update:   update the ollama-installer block in %s to:\n                   %s\n", change.File, change.Line)
+	default:
+		fmt.Fprintf(w, "  PATH update:   append to %s:\n                   %s\n", change.File, change.Line)
+	}
+	if change := plan.PathChange; change != nil && change.Script != "" {
+		fmt.Fprintf(w, "  Env script:    %s\n", change.Script)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"timberlea-upload-tool/installer"
+)
+
+/* Prefix of the variables that --env may set */
+const ollamaEnvPrefix = "OLLAMA_"
+
+/*
+runEnv implements the env command, which prints the env script for a shell
+so the installation can be used in the current terminal straight away:
+
+	eval "$(ollama-installer env)"
+	ollama-installer env --shell fish | source
+
+The script puts the install directory on PATH and exports the OLLAMA_*
+settings given with --env or configured with `config set env`.
+
+Parameters:
+  - args: Arguments after `env`
+
+Returns:
+  - int: The process exit status
+*/
+func runEnv(args []string) int {
+	flags := flag.NewFlagSet("env", flag.ExitOnError)
+	shellName := flags.String("shell", "", "shell to print the script for: bash, zsh, sh, fish, tcsh, csh or nu (default: the login shell)")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: ollama-installer env [--shell SHELL]")
+		return exitUsage
+	}
+
+	if *shellName == "" {
+		*shellName = installer.DetectShell()
+	}
+	shell := installer.ParseShell(*shellName)
+
+	/* Only the configured env settings apply; install flags are not accepted here */
+	opts, _, err := parseInstallArgs(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get home directory: %v\n", err)
+		return exitFailure
+	}
+
+	binDir := opts.InstallDir
+	if binDir == "" {
+		binDir = installer.BinDir(homeDir)
+	}
+	fmt.Print(shell.EnvScript(homeDir, binDir, opts.Env))
+	return exitOK
+}
+
+/*
+parseEnvSetting parses a NAME=VALUE setting for the env script. Only
+OLLAMA_* variables may be set, other than the installer's own
+OLLAMA_INSTALLER_* configuration.
+
+Parameters:
+  - setting: The setting (e.g., OLLAMA_HOST=0.0.0.0:11434)
+
+Returns:
+  - string: The variable name
+  - string: The value
+  - error: An error if the setting is malformed or names another variable
+*/
+func parseEnvSetting(setting string) (string, string, error) {
+	name, value, ok := strings.Cut(setting, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("must be NAME=VALUE")
+	}
+	if !strings.HasPrefix(name, ollamaEnvPrefix) || strings.HasPrefix(name, configEnvPrefix) {
+		return "", "", fmt.Errorf("%s is not an OLLAMA_* server setting", name)
+	}
+	for _, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return "", "", fmt.Errorf("invalid variable name %s", name)
+		}
+	}
+	return name, value, nil
+}
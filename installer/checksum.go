@@ -0,0 +1,173 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+/* Name of the checksum list published with each Ollama release */
+const checksumFileName = "sha256sum.txt"
+
+/*
+fetchExpectedChecksum looks up the published SHA-256 of a release asset.
+It reads the release's checksum list from GitHub first, so a mirror cannot
+serve a tampered asset together with a matching checksum. The checksum
+lists on mirrors are only consulted after GitHub, and only when the caller
+trusts them by passing them in mirrors.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - client: HTTP client to use
+  - mirrors: Mirror base URLs whose checksum lists are trusted, in order (may be nil)
+  - version: The version tag (e.g., "v0.1.20")
+  - asset: The asset file name (e.g., "ollama-linux-amd64.tgz")
+
+Returns:
+  - string: The lowercase hex SHA-256 of the asset
+  - error: An error if no source provides a checksum for the asset
+*/
+func fetchExpectedChecksum(ctx context.Context, client *http.Client, mirrors []string, version, asset string) (string, error) {
+	sources := []string{GitHubDownloadURL}
+	for _, mirror := range mirrors {
+		if !slices.Contains(sources, mirror) {
+			sources = append(sources, mirror)
+		}
+	}
+
+	var lastErr error
+	for _, mirror := range sources {
+		sum, err := fetchChecksum(ctx, client, releaseFileURL(mirror, version, checksumFileName), asset)
+		if err == nil {
+			return sum, nil
+		}
+		lastErr = err
+	}
+
+	if len(sources) == 1 {
+		return "", fmt.Errorf("no checksum available for %s from GitHub (checksums published by mirrors are not trusted by default): %w", asset, lastErr)
+	}
+	return "", fmt.Errorf("no checksum available for %s: %w", asset, lastErr)
+}
+
+/*
+fetchChecksum downloads a checksum list and returns the entry for asset.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - client: HTTP client to use
+  - url: URL of the checksum list
+  - asset: The asset file name to look up
+
+Returns:
+  - string: The lowercase hex SHA-256 of the asset
+  - error: Any error fetching the list or if the asset is not listed
+*/
+func fetchChecksum(ctx context.Context, client *http.Client, url, asset string) (string, error) {
+	/* The client has no overall timeout, so limit this small request here */
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", networkError(fmt.Errorf("failed to fetch %s: %w", url, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError(url, resp)
+	}
+
+	sum, ok := parseChecksums(resp.Body, asset)
+	if !ok {
+		return "", fmt.Errorf("%s has no entry for %s: %w", url, asset, ErrAssetNotFound)
+	}
+	return sum, nil
+}
+
+/*
+parseChecksums finds the checksum of asset in sha256sum-style output, where
+each line is "<hex digest>  <file name>" and file names may be prefixed
+with "./" or "*".
+
+Parameters:
+  - r: Reader over the checksum list
+  - asset: The asset file name to look up
+
+Returns:
+  - string: The lowercase hex digest
+  - bool: true if the asset was found
+*/
+func parseChecksums(r io.Reader, asset string) (string, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		name := strings.TrimPrefix(strings.TrimPrefix(fields[1], "*"), "./")
+		if name == asset {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+/*
+verifyChecksum compares the SHA-256 of a file with the expected digest.
+
+Parameters:
+  - path: Path to the file to hash
+  - expected: The expected lowercase hex digest
+
+Returns:
+  - error: An error if the file cannot be read or the digest differs
+*/
+func verifyChecksum(path, expected string) error {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		return &ChecksumError{Path: path, Got: actual, Expected: expected}
+	}
+	return nil
+}
+
+/*
+fileSHA256 returns the lowercase hex SHA-256 of a file.
+
+Parameters:
+  - path: Path to the file to hash
+
+Returns:
+  - string: The hex digest
+  - error: Any error reading the file
+*/
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,208 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+CI describes the continuous integration system the installer runs in.
+Editing shell configuration files is pointless there, since every step
+starts a fresh shell; instead each system has its own way of passing PATH
+and values on to later steps.
+*/
+type CI struct {
+	/* Name is the system's name, for messages */
+	Name string
+
+	/* PathFile receives directories to add to PATH in later steps ($GITHUB_PATH) */
+	PathFile string
+
+	/* OutputFile receives the step's outputs as name=value lines ($GITHUB_OUTPUT) */
+	OutputFile string
+
+	/* EnvFile is a shell script sourced before every later step ($BASH_ENV on CircleCI) */
+	EnvFile string
+
+	/* ExportFile is the default dotenv file for Options.ExportFile (ollama.env on GitLab CI) */
+	ExportFile string
+}
+
+/* Name of the dotenv file written on GitLab CI, for an artifacts:reports:dotenv entry */
+const gitlabExportFileName = "ollama.env"
+
+/*
+DetectCI recognizes GitHub Actions, GitLab CI and CircleCI from the
+variables they set, and any other CI system that sets CI.
+
+Returns:
+  - CI: The detected system
+  - bool: Whether the installer runs in CI
+*/
+func DetectCI() (CI, bool) {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return CI{Name: "GitHub Actions", PathFile: os.Getenv("GITHUB_PATH"), OutputFile: os.Getenv("GITHUB_OUTPUT")}, true
+	case os.Getenv("GITLAB_CI") == "true":
+		dir := os.Getenv("CI_PROJECT_DIR")
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		return CI{Name: "GitLab CI", ExportFile: filepath.Join(dir, gitlabExportFileName)}, true
+	case os.Getenv("CIRCLECI") == "true":
+		return CI{Name: "CircleCI", EnvFile: os.Getenv("BASH_ENV")}, true
+	}
+
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false":
+		return CI{}, false
+	}
+	return CI{Name: "CI"}, true
+}
+
+/*
+CIPathUpdater is the PathUpdater used in CI. It puts the install directory
+on PATH for the job's later steps through the system's PATH or env file,
+and leaves shell configuration files alone.
+*/
+type CIPathUpdater struct {
+	/* CI is the system to update PATH in */
+	CI CI
+
+	/* Observer receives EventPathUpdated events (may be nil) */
+	Observer Observer
+}
+
+/*
+UpdatePath implements PathUpdater. Without a PATH or env file, as on
+GitLab CI, it reports the command that puts binDir on PATH instead.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binDir: The directory to add to PATH
+
+Returns:
+  - error: Any error appending to the PATH or env file
+*/
+func (u *CIPathUpdater) UpdatePath(ctx context.Context, binDir string) error {
+	change, err := u.PlanPath(ctx, binDir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case change.Present:
+		notifyf(u.Observer, EventInfo, "PATH already contains %s", binDir)
+	case change.Manual:
+		notifyf(u.Observer, EventInfo, "Running in %s: shell configuration was not modified. To use ollama in later commands, run: %s", u.CI.Name, change.Line)
+	default:
+		if err := appendLines(change.File, change.Line); err != nil {
+			return err
+		}
+		notify(u.Observer, Event{
+			Kind:    EventPathUpdated,
+			Message: fmt.Sprintf("Added %s to PATH for later %s steps", binDir, u.CI.Name),
+			Path:    change.File,
+		})
+	}
+	return nil
+}
+
+/*
+PlanPath implements PathPlanner, reporting the file and line UpdatePath
+would append to. Present is set when PATH already includes binDir, and
+Manual when the system has no file for it.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binDir: The directory to add to PATH
+
+Returns:
+  - PathChange: The file and line that would be appended
+  - error: Any error from ctx
+*/
+func (u *CIPathUpdater) PlanPath(ctx context.Context, binDir string) (PathChange, error) {
+	if err := ctx.Err(); err != nil {
+		return PathChange{}, err
+	}
+
+	exportLine := fmt.Sprintf(`export PATH=%s:"$PATH"`, shellQuote(binDir))
+	switch {
+	case pathIndex(filepath.SplitList(os.Getenv("PATH")), binDir) >= 0:
+		return PathChange{Line: binDir, Present: true}, nil
+	case u.CI.PathFile != "":
+		return PathChange{File: u.CI.PathFile, Line: binDir}, nil
+	case u.CI.EnvFile != "":
+		return PathChange{File: u.CI.EnvFile, Line: exportLine}, nil
+	}
+	return PathChange{Line: exportLine, Manual: true}, nil
+}
+
+/*
+exportResult makes the installed version and paths available to later CI
+steps: as the step outputs version, path and bin-dir in the CI system's
+output file, and as OLLAMA_VERSION, OLLAMA_BINARY and OLLAMA_BIN_DIR in
+Options.ExportFile, a dotenv file (as read by GitLab's dotenv reports).
+Without Options.ExportFile, the CI system's default dotenv file is
+written, if it has one.
+
+Parameters:
+  - result: The completed installation
+
+Returns:
+  - error: Any error writing a file, matching ErrPathUpdate
+*/
+func (i *Installer) exportResult(result Result) error {
+	if ci := i.opts.CI; ci != nil && ci.OutputFile != "" {
+		err := appendLines(ci.OutputFile,
+			"version="+result.Version,
+			"path="+result.BinaryPath,
+			"bin-dir="+result.PathDir)
+		if err != nil {
+			return withKind(ErrPathUpdate, err)
+		}
+		notifyf(i.opts.Observer, EventInfo, "Set the %s step outputs version, path and bin-dir", ci.Name)
+	}
+
+	path := i.opts.ExportFile
+	if path == "" && i.opts.CI != nil {
+		path = i.opts.CI.ExportFile
+	}
+	if path != "" {
+		content := fmt.Sprintf("OLLAMA_VERSION=%s\nOLLAMA_BINARY=%s\nOLLAMA_BIN_DIR=%s\n", result.Version, result.BinaryPath, result.PathDir)
+		if err := os.WriteFile(path, []byte(content), configFileMode); err != nil {
+			return withKind(ErrPathUpdate, fileError(fmt.Errorf("failed to write %s: %w", path, err), nil))
+		}
+		notify(i.opts.Observer, Event{Kind: EventInfo, Message: fmt.Sprintf("Wrote the version and paths to %s", path), Path: path})
+	}
+	return nil
+}
+
+/*
+appendLines appends lines to a file, creating it if needed. CI systems
+read these files after the step, so earlier contents must be kept.
+
+Parameters:
+  - path: Path of the file
+  - lines: The lines to append
+
+Returns:
+  - error: Any error opening or writing the file
+*/
+func appendLines(path string, lines ...string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, configFileMode)
+	if err != nil {
+		return fileError(fmt.Errorf("failed to open %s: %w", path, err), nil)
+	}
+	if _, err := file.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package installer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectCIGitLabExportFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		projectDir string
+		want       func(t *testing.T) string
+	}{
+		{
+			name:       "project directory",
+			projectDir: "/builds/group/project",
+			want:       func(*testing.T) string { return filepath.Join("/builds/group/project", "ollama.env") },
+		},
+		{
+			name: "current directory",
+			want: func(t *testing.T) string {
+				dir := t.TempDir()
+				t.Chdir(dir)
+				return filepath.Join(dir, "ollama.env")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_ACTIONS", "")
+			t.Setenv("GITLAB_CI", "true")
+			t.Setenv("CI_PROJECT_DIR", tt.projectDir)
+			want := tt.want(t)
+
+			ci, ok := DetectCI()
+			if !ok || ci.Name != "GitLab CI" {
+				t.Fatalf("DetectCI() = %+v, %v, want GitLab CI", ci, ok)
+			}
+			if ci.ExportFile != want {
+				t.Errorf("ExportFile = %q, want %q", ci.ExportFile, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	/* Markers delimiting the block the installer manages in shell configuration files */
+	blockStartMarker = "# >>> ollama-installer >>>"
+	blockEndMarker   = "# <<< ollama-installer <<<"
+
+	/* Comment at the top of the managed block */
+	blockComment = "# Managed by ollama-installer; changes inside this block are overwritten"
+
+	/* Suffix of the copy of a shell configuration file kept before the first edit */
+	configBackupSuffix = ".ollama-installer.bak"
+)
+
+/*
+managedBlock returns the lines of the managed block holding line.
+
+Parameters:
+  - line: The configuration line to manage
+
+Returns:
+  - []string: The block, including its markers
+*/
+func managedBlock(line string) []string {
+	return []string{blockStartMarker, blockComment, line, blockEndMarker}
+}
+
+/*
+findBlock locates the managed block in a file's lines.
+
+Parameters:
+  - lines: The file's lines
+
+Returns:
+  - int: Index of the start marker
+  - int: Index of the end marker
+  - bool: Whether a complete block was found
+*/
+func findBlock(lines []string) (int, int, bool) {
+	start := -1
+	for n, line := range lines {
+		switch strings.TrimSpace(line) {
+		case blockStartMarker:
+			start = n
+		case blockEndMarker:
+			if start >= 0 {
+				return start, n, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+/*
+blockContent returns the configuration lines inside the managed block of
+content, excluding the markers and comments.
+
+Parameters:
+  - content: The file's contents
+
+Returns:
+  - []string: The lines in the block
+  - bool: Whether the file has a managed block
+*/
+func blockContent(content string) ([]string, bool) {
+	lines := splitLines(content)
+	start, end, ok := findBlock(lines)
+	if !ok {
+		return nil, false
+	}
+
+	var inner []string
+	for _, line := range lines[start+1 : end] {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			inner = append(inner, strings.TrimSuffix(line, "\r"))
+		}
+	}
+	return inner, true
+}
+
+/*
+setBlock returns content with its managed block set to hold line. An
+existing block is replaced in place; otherwise the block is appended after
+a blank line, or directly after the last line if the file does not end in
+a newline. An empty line removes the block together with what was added
+before it, so setting and removing restores the original content byte for
+byte. Files with CRLF line endings get a block with CRLF line endings, and
+the rest of the file is kept as it is.
+
+Parameters:
+  - content: The file's contents
+  - line: The configuration line to manage, or empty to remove the block
+
+Returns:
+  - string: The new contents
+*/
+func setBlock(content, line string) string {
+	lines := splitLines(content)
+	final := content == "" || strings.HasSuffix(content, "\n")
+
+	/* Lines keep their own "\r", so the block's lines need one in CRLF files */
+	cr := ""
+	if strings.Contains(content, "\r\n") {
+		cr = "\r"
+	}
+	var block []string
+	if line != "" {
+		for _, blockLine := range managedBlock(line) {
+			block = append(block, blockLine+cr)
+		}
+	}
+
+	start, end, ok := findBlock(lines)
+	switch {
+	case ok && line != "":
+		lines = append(lines[:start:start], append(block, lines[end+1:]...)...)
+	case ok:
+		atEnd := end == len(lines)-1
+		switch {
+		case start > 0 && strings.TrimSuffix(lines[start-1], "\r") == "":
+			start--
+		case start > 0 && atEnd:
+			/* The block was added to a file without a final newline */
+			lines[start-1] = strings.TrimSuffix(lines[start-1], "\r")
+			final = false
+		}
+		lines = append(lines[:start:start], lines[end+1:]...)
+	case line != "":
+		switch {
+		case len(lines) == 0:
+		case final:
+			lines = append(lines, cr)
+		default:
+			lines[len(lines)-1] += cr
+		}
+		lines = append(lines, block...)
+		final = true
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+	content = strings.Join(lines, "\n")
+	if final {
+		content += "\n"
+	}
+	return content
+}
+
+/*
+splitLines splits file contents into lines without their "\n" line
+endings. A "\r" before the "\n" is kept, so joining the lines again with
+"\n" gives back the original contents.
+
+Parameters:
+  - content: The file's contents
+
+Returns:
+  - []string: The lines (none for an empty file)
+*/
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+/*
+readConfigFile reads a shell configuration file, treating a missing file
+as empty.
+
+Parameters:
+  - path: Path of the file
+
+Returns:
+  - string: The file's contents
+  - bool: Whether the file exists
+  - error: Any error reading an existing file
+*/
+func readConfigFile(path string) (string, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), true, nil
+}
+
+/*
+editBlock sets or removes the managed block in a shell configuration file
+(see setBlock). An existing file is first copied to a backup next to it,
+and the new contents are written to a temporary file and renamed into
+place so that the file is never left half-written. The backup is only
+written the first time, so it keeps the file as it was before the
+installer ever changed it. Symbolic links, as used
+by dotfile managers, are followed so that the link itself is kept. The
+installer's own fish snippet is removed once empty; other files are kept
+even if empty, since whether a file exists can decide which others a shell
+reads. Nothing is written if the contents would not change.
+
+Parameters:
+  - path: Path of the file
+  - line: The configuration line to manage, or empty to remove the block
+
+Returns:
+  - string: A unified diff of the change (empty if nothing changed)
+  - string: Path of the backup (empty if the file did not exist or was unchanged)
+  - error: Any error reading, backing up or writing the file
+*/
+func editBlock(path, line string) (string, string, error) {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	old, existed, err := readConfigFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	content := setBlock(old, line)
+	if content == old {
+		return "", "", nil
+	}
+	diff := unifiedDiff(path, old, content)
+
+	mode := os.FileMode(configFileMode)
+	backupPath := ""
+	if existed {
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		backupPath = path + configBackupSuffix
+		if _, err := os.Lstat(backupPath); errors.Is(err, fs.ErrNotExist) {
+			if err := os.WriteFile(backupPath, []byte(old), mode); err != nil {
+				return "", "", fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		}
+	}
+
+	if content == "" && filepath.Base(path) == fishConfFileName {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", "", fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return diff, backupPath, nil
+	}
+
+	/* fish and nushell keep their configuration in a subdirectory */
+	if err := os.MkdirAll(filepath.Dir(path), executableMode); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	partialPath := path + partialSuffix
+	if err := os.WriteFile(partialPath, []byte(content), mode); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(partialPath, path); err != nil {
+		os.Remove(partialPath)
+		return "", "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return diff, backupPath, nil
+}
+
+/*
+unifiedDiff returns a unified diff between two versions of a file. The
+installer only ever changes one region of a file, so the diff is a single
+hunk covering the lines between the unchanged prefix and suffix, with up
+to three lines of context.
+
+Parameters:
+  - path: Path of the file, for the headers
+  - old: The previous contents
+  - new: The new contents
+
+Returns:
+  - string: The diff, or empty if the contents are the same
+*/
+func unifiedDiff(path, old, new string) string {
+	const context = 3
+
+	a, b := diffLines(old), diffLines(new)
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(a) && prefix == len(b) {
+		return ""
+	}
+
+	before := min(prefix, context)
+	after := min(suffix, context)
+	start := prefix - before
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", path, path)
+	fmt.Fprintf(&diff, "@@ -%s +%s @@\n",
+		hunkRange(start, before+len(a)-prefix-suffix+after),
+		hunkRange(start, before+len(b)-prefix-suffix+after))
+	for _, line := range a[start:prefix] {
+		diff.WriteString(" " + line + "\n")
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		diff.WriteString("-" + line + "\n")
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		diff.WriteString("+" + line + "\n")
+	}
+	for _, line := range a[len(a)-suffix : len(a)-suffix+after] {
+		diff.WriteString(" " + line + "\n")
+	}
+	return diff.String()
+}
+
+/*
+diffLines splits file contents into lines for a diff, without "\r\n" or
+"\n" line endings.
+
+Parameters:
+  - content: The file's contents
+
+Returns:
+  - []string: The lines
+*/
+func diffLines(content string) []string {
+	lines := splitLines(content)
+	for n, line := range lines {
+		lines[n] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+/*
+hunkRange formats the line range of a unified diff hunk.
+
+Parameters:
+  - start: Zero-based index of the first line
+  - count: Number of lines
+
+Returns:
+  - string: The range (e.g., "3,4", or "2,0" for an empty range after line 2)
+*/
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
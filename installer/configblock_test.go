@@ -0,0 +1,178 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPathLine = `export PATH="$HOME/bin:$PATH"`
+
+func TestSetBlock(t *testing.T) {
+	block := blockStartMarker + "\n" + blockComment + "\n" + testPathLine + "\n" + blockEndMarker + "\n"
+	crlfBlock := strings.ReplaceAll(block, "\n", "\r\n")
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty file", content: "", want: block},
+		{name: "trailing newline", content: "FOO=1\n", want: "FOO=1\n\n" + block},
+		{name: "no trailing newline", content: "FOO=1", want: "FOO=1\n" + block},
+		{name: "blank last line", content: "FOO=1\n\n", want: "FOO=1\n\n\n" + block},
+		{name: "whitespace last line", content: "FOO=1\n  ", want: "FOO=1\n  \n" + block},
+		{name: "CRLF", content: "FOO=1\r\n", want: "FOO=1\r\n\r\n" + crlfBlock},
+		{name: "CRLF without trailing newline", content: "FOO=1\r\nBAR=2", want: "FOO=1\r\nBAR=2\r\n" + crlfBlock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added := setBlock(tt.content, testPathLine)
+			if added != tt.want {
+				t.Errorf("setBlock() = %q, want %q", added, tt.want)
+			}
+			if again := setBlock(added, testPathLine); again != added {
+				t.Errorf("setBlock() again = %q, want unchanged %q", again, added)
+			}
+			lines, ok := blockContent(added)
+			if !ok || len(lines) != 1 || lines[0] != testPathLine {
+				t.Errorf("blockContent() = %q, %v, want [%q]", lines, ok, testPathLine)
+			}
+			if removed := setBlock(added, ""); removed != tt.content {
+				t.Errorf("setBlock() remove = %q, want original %q", removed, tt.content)
+			}
+		})
+	}
+}
+
+func TestSetBlockExisting(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    string
+		want    string
+	}{
+		{
+			name:    "update in place",
+			content: "A=1\n\n" + blockStartMarker + "\n" + blockComment + "\nexport PATH=\"/old:$PATH\"\n" + blockEndMarker + "\nB=2\n",
+			line:    testPathLine,
+			want:    "A=1\n\n" + blockStartMarker + "\n" + blockComment + "\n" + testPathLine + "\n" + blockEndMarker + "\nB=2\n",
+		},
+		{
+			name:    "update in a CRLF file",
+			content: "A=1\r\n" + blockStartMarker + "\r\nexport PATH=\"/old:$PATH\"\r\n" + blockEndMarker + "\r\n",
+			line:    testPathLine,
+			want:    "A=1\r\n" + blockStartMarker + "\r\n" + blockComment + "\r\n" + testPathLine + "\r\n" + blockEndMarker + "\r\n",
+		},
+		{
+			name:    "remove from the middle",
+			content: "A=1\n\n" + blockStartMarker + "\n" + testPathLine + "\n" + blockEndMarker + "\nB=2",
+			want:    "A=1\nB=2",
+		},
+		{
+			name:    "remove the only content",
+			content: blockStartMarker + "\n" + testPathLine + "\n" + blockEndMarker + "\n",
+			want:    "",
+		},
+		{
+			name:    "remove without a block",
+			content: "A=1\n",
+			want:    "A=1\n",
+		},
+		{
+			name:    "incomplete block is left alone",
+			content: "A=1\n" + blockStartMarker + "\n" + testPathLine + "\n",
+			want:    "A=1\n" + blockStartMarker + "\n" + testPathLine + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setBlock(tt.content, tt.line); got != tt.want {
+				t.Errorf("setBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockContentCRLF(t *testing.T) {
+	content := "A=1\r\n" + blockStartMarker + "\r\n" + blockComment + "\r\n" + testPathLine + "\r\n" + blockEndMarker + "\r\n"
+	lines, ok := blockContent(content)
+	if !ok || len(lines) != 1 || lines[0] != testPathLine {
+		t.Errorf("blockContent() = %q, %v, want [%q]", lines, ok, testPathLine)
+	}
+}
+
+func TestEditBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		symlink bool
+	}{
+		{name: "regular file", content: "FOO=1"},
+		{name: "CRLF file", content: "FOO=1\r\nBAR=2\r\n"},
+		{name: "symlinked dotfile", content: "FOO=1\n", symlink: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, ".profile")
+			target := path
+			if tt.symlink {
+				target = filepath.Join(dir, "dotfiles", "profile")
+				if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink(target, path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(target, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			diff, backup, err := editBlock(path, testPathLine)
+			if err != nil || diff == "" || backup != target+configBackupSuffix {
+				t.Fatalf("editBlock() = %q, %q, %v, want a diff and backup %s", diff, backup, err, target+configBackupSuffix)
+			}
+			if diff, _, err := editBlock(path, testPathLine); err != nil || diff != "" {
+				t.Errorf("editBlock() again = %q, %v, want no change", diff, err)
+			}
+			if _, _, err := editBlock(path, `export PATH="/opt/ollama:$PATH"`); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := editBlock(path, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := readFileString(t, target); got != tt.content {
+				t.Errorf("after removing, file = %q, want %q", got, tt.content)
+			}
+			if got := readFileString(t, backup); got != tt.content {
+				t.Errorf("backup = %q, want the original %q", got, tt.content)
+			}
+			if info, err := os.Stat(target); err != nil {
+				t.Error(err)
+			} else if info.Mode().Perm() != 0600 {
+				t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+			}
+			if tt.symlink {
+				if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+					t.Errorf("%s is no longer a symlink", path)
+				}
+			}
+		})
+	}
+}
+
+func readFileString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,485 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	/* Chunked download tuning */
+	DefaultConnections = 4
+	minChunkSize       = 8 << 20
+	maxChunkRetries    = 3
+)
+
+/* Pause before retrying a failed chunk (shortened by tests) */
+var chunkRetryDelay = 2 * time.Second
+
+/*
+Downloader fetches a release asset and verifies it against its checksum.
+*/
+type Downloader interface {
+	/*
+		Download saves the file available at any of urls (the same asset on
+		different mirrors) to filePath, and fails unless its SHA-256 matches
+		checksum.
+	*/
+	Download(ctx context.Context, urls []string, filePath, checksum string) error
+}
+
+/*
+HTTPDownloader is the default Downloader. It holds the settings shared by
+every request of a download so that parallel chunks and mirror failover all
+use the same client, connection limit and bandwidth limit.
+*/
+type HTTPDownloader struct {
+	/* Client performs the HTTP (and file://) requests */
+	Client *http.Client
+
+	/* Connections is the maximum number of concurrent range requests */
+	Connections int
+
+	/* Limiter caps the combined download rate; nil means unlimited */
+	Limiter *RateLimiter
+
+	/* Observer receives download progress and status events (may be nil) */
+	Observer Observer
+}
+
+/*
+Download implements Downloader. It ranks the mirrors by response time and
+downloads from the fastest, failing over to the others as needed.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - urls: The asset URL on each mirror, in configured order
+  - filePath: The local file path where the download should be saved
+  - checksum: The expected lowercase hex SHA-256 of the asset
+
+Returns:
+  - error: Any error if no mirror produced a file with the expected checksum
+*/
+func (d *HTTPDownloader) Download(ctx context.Context, urls []string, filePath, checksum string) error {
+	return d.downloadVerified(ctx, rankMirrors(ctx, d.Client, urls, d.Observer), filePath, checksum)
+}
+
+/*
+downloadFile downloads a file to the specified path, trying each URL in turn.
+It uses Go's native HTTP client with progress indicators and follows redirects.
+When the first server supports byte ranges, the file is fetched in chunks
+(in parallel when d.Connections allows more than one) that resume on the
+next URL if a transfer fails; otherwise each URL is streamed in one request
+until one succeeds. Download progress is reported to d.Observer.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - urls: The URLs of the same file on each mirror, in order of preference
+  - filePath: The local file path where the download should be saved
+
+Returns:
+  - error: Any error that occurred during the download process
+*/
+func (d *HTTPDownloader) downloadFile(ctx context.Context, urls []string, filePath string) error {
+	notify(d.Observer, Event{
+		Kind:    EventDownloadStarted,
+		Message: fmt.Sprintf("Downloading Ollama from %s...", urls[0]),
+		URL:     urls[0],
+		Path:    filePath,
+	})
+
+	// Use range requests when the server supports them
+	size, ranges, err := probeRangeSupport(ctx, d.Client, urls[0])
+	if err != nil {
+		notify(d.Observer, Event{Kind: EventWarning, Message: err.Error(), URL: urls[0], Err: err})
+	} else if ranges {
+		return d.downloadChunked(ctx, urls, filePath, size)
+	}
+
+	for i, url := range urls {
+		if i > 0 {
+			notify(d.Observer, Event{
+				Kind:    EventDownloadStarted,
+				Message: fmt.Sprintf("Retrying download from %s...", url),
+				URL:     url,
+				Path:    filePath,
+			})
+		}
+		if err = d.downloadStream(ctx, url, filePath); err == nil || ctx.Err() != nil {
+			return err
+		}
+		notify(d.Observer, Event{Kind: EventWarning, Message: err.Error(), URL: url, Err: err})
+	}
+	return err
+}
+
+/*
+downloadStream downloads a file from the given URL in a single request.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - url: The URL to download from
+  - filePath: The local file path where the download should be saved
+
+Returns:
+  - error: Any error that occurred during the download process
+*/
+func (d *HTTPDownloader) downloadStream(ctx context.Context, url, filePath string) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Create request with context
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Make the request
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return networkError(fmt.Errorf("failed to download file: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(url, resp)
+	}
+
+	// Create the output file
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fileError(fmt.Errorf("failed to create file: %w", err), nil)
+	}
+	defer out.Close()
+
+	// Copy with progress sized from the response
+	progress := NewProgress(resp.ContentLength, d.Observer)
+	defer progress.Finish()
+
+	_, err = io.Copy(out, d.wrapBody(ctx, cancel, resp.Body, progress))
+	if err != nil {
+		return transferError(fmt.Errorf("failed to save file: %w", err))
+	}
+
+	return nil
+}
+
+/*
+chunk is a byte range of the download handled by one connection.
+The range is inclusive of both start and end, matching HTTP Range semantics.
+*/
+type chunk struct {
+	start int64
+	end   int64
+}
+
+/*
+probeRangeSupport sends a HEAD request to find out whether the server accepts
+byte range requests and how large the file is.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - client: HTTP client to use
+  - url: The URL of the file
+
+Returns:
+  - int64: The size of the file in bytes (-1 if unknown)
+  - bool: true if the server advertises "Accept-Ranges: bytes" and a size
+  - error: Any error that occurred during the request
+*/
+func probeRangeSupport(ctx context.Context, client *http.Client, url string) (int64, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return -1, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1, false, networkError(fmt.Errorf("failed to probe %s: %w", url, err))
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return -1, false, newStatusError(url, resp)
+	}
+
+	ranges := resp.Header.Get("Accept-Ranges") == "bytes" && resp.ContentLength > 0
+	return resp.ContentLength, ranges, nil
+}
+
+/*
+splitChunks divides a file of the given size into at most n contiguous
+ranges, never making a range smaller than minChunkSize unless the file
+itself is smaller.
+
+Parameters:
+  - size: Total file size in bytes
+  - n: Maximum number of chunks
+
+Returns:
+  - []chunk: The byte ranges covering the whole file
+*/
+func splitChunks(size int64, n int) []chunk {
+	n = int(min(int64(n), max(size/minChunkSize, 1)))
+	chunkSize := (size + int64(n) - 1) / int64(n)
+
+	chunks := make([]chunk, 0, n)
+	for start := int64(0); start < size; start += chunkSize {
+		chunks = append(chunks, chunk{start: start, end: min(start+chunkSize, size) - 1})
+	}
+	return chunks
+}
+
+/*
+downloadChunked downloads a file using up to d.Connections concurrent range
+requests.
+The output file is preallocated to its final size and each connection writes
+its range in place. A failed chunk is retried from the last byte it wrote,
+moving on to the next mirror each time so a failing mirror is abandoned
+mid-download without losing the data already fetched.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - urls: The asset URL on each mirror, in the order they should be tried
+  - filePath: The local file path where the download should be saved
+  - size: The size of the file in bytes, as reported by probeRangeSupport
+
+Returns:
+  - error: Any error that occurred during the download process
+*/
+func (d *HTTPDownloader) downloadChunked(ctx context.Context, urls []string, filePath string, size int64) error {
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fileError(fmt.Errorf("failed to create file: %w", err), nil)
+	}
+	defer out.Close()
+
+	if err := out.Truncate(size); err != nil {
+		return fileError(fmt.Errorf("failed to preallocate file: %w", err), nil)
+	}
+
+	chunks := splitChunks(size, max(d.Connections, 1))
+	if len(chunks) > 1 {
+		notifyf(d.Observer, EventInfo, "Using %d connections", len(chunks))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	progress := NewProgress(size, d.Observer)
+	defer progress.Finish()
+	errs := make(chan error, len(chunks))
+	var wg sync.WaitGroup
+
+	for _, c := range chunks {
+		wg.Add(1)
+		go func(c chunk) {
+			defer wg.Done()
+			if err := d.downloadChunk(ctx, urls, out, c, progress); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(c)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+/*
+downloadChunk fetches one byte range and writes it at the matching offset
+of the output file. Interrupted transfers resume from where they stopped on
+the next mirror in the list; the retry budget is only spent when an attempt
+makes no progress at all.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - urls: The asset URL on each mirror, in the order they should be tried
+  - out: The preallocated output file
+  - c: The byte range to download
+  - progress: Shared progress display for all chunks
+
+Returns:
+  - error: Any error that persisted after all retries
+*/
+func (d *HTTPDownloader) downloadChunk(ctx context.Context, urls []string, out *os.File, c chunk, progress *Progress) error {
+	offset := c.start
+	mirror := 0
+	failures := 0
+
+	for {
+		written, err := d.fetchRange(ctx, urls[mirror], out, offset, c.end, progress)
+		offset += written
+		if err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		/* No mirror can help when the file cannot be written */
+		if errors.Is(err, ErrDiskFull) {
+			return err
+		}
+
+		if written == 0 {
+			failures++
+		} else {
+			failures = 0
+		}
+		if failures > maxChunkRetries*len(urls) {
+			return fmt.Errorf("bytes %d-%d failed after %d retries: %w", c.start, c.end, failures-1, err)
+		}
+
+		if len(urls) > 1 {
+			mirror = (mirror + 1) % len(urls)
+			notify(d.Observer, Event{
+				Kind:    EventWarning,
+				Message: fmt.Sprintf("%v, resuming bytes %d-%d from %s", err, offset, c.end, urls[mirror]),
+				URL:     urls[mirror],
+				Err:     err,
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(chunkRetryDelay):
+		}
+	}
+}
+
+/*
+downloadVerified downloads an asset from the ranked mirrors and checks it
+against the expected SHA-256. If the combined download does not match, each
+mirror is tried on its own so a single bad mirror cannot fail the install.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - urls: The asset URL on each mirror, in the order they should be tried
+  - filePath: The local file path where the download should be saved
+  - checksum: The expected lowercase hex SHA-256 of the asset
+
+Returns:
+  - error: Any error if no mirror produced a file with the expected checksum
+*/
+func (d *HTTPDownloader) downloadVerified(ctx context.Context, urls []string, filePath, checksum string) error {
+	attempts := [][]string{urls}
+	if len(urls) > 1 {
+		for _, u := range urls {
+			attempts = append(attempts, []string{u})
+		}
+	}
+
+	var lastErr error
+	for _, attempt := range attempts {
+		if err := d.downloadFile(ctx, attempt, filePath); err != nil {
+			lastErr = err
+		} else if err := verifyChecksum(filePath, checksum); err != nil {
+			lastErr = err
+		} else {
+			notify(d.Observer, Event{
+				Kind:     EventVerify,
+				Message:  fmt.Sprintf("Verified SHA-256 %s", checksum),
+				Path:     filePath,
+				Checksum: checksum,
+			})
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(lastErr, ErrDiskFull) {
+			return lastErr
+		}
+		notify(d.Observer, Event{Kind: EventWarning, Message: lastErr.Error(), Err: lastErr})
+	}
+
+	return lastErr
+}
+
+/*
+fetchRange performs a single range request and copies the response body
+into out starting at offset.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - url: The URL to download from
+  - out: The output file
+  - offset: First byte to request
+  - end: Last byte to request (inclusive)
+  - progress: Progress display to report bytes to
+
+Returns:
+  - int64: Number of bytes written, even when an error occurred
+  - error: Any error that occurred during the request or copy
+*/
+func (d *HTTPDownloader) fetchRange(ctx context.Context, url string, out *os.File, offset, end int64, progress *Progress) (int64, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, end))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, networkError(fmt.Errorf("failed to download range: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, newStatusError(url, resp)
+	}
+
+	var start int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+		return 0, withKind(ErrNetwork, fmt.Errorf("range request for offset %d returned %q", offset, resp.Header.Get("Content-Range")))
+	}
+
+	reader := d.wrapBody(ctx, cancel, io.LimitReader(resp.Body, end-offset+1), progress)
+	written, err := io.Copy(io.NewOffsetWriter(out, offset), reader)
+	if err != nil {
+		return written, transferError(fmt.Errorf("failed to save range: %w", err))
+	}
+	if written != end-offset+1 {
+		return written, withKind(ErrNetwork, fmt.Errorf("range ended early after %d of %d bytes", written, end-offset+1))
+	}
+
+	return written, nil
+}
+
+/*
+wrapBody layers cancellation, bandwidth limiting and progress reporting
+over a response body. The limiter is shared by every body of the download,
+so the rate cap applies to the combined throughput of all chunks and mirrors.
+Checking the context here also stops bodies that do not observe it
+themselves, such as local files served for file:// mirrors. A body that
+delivers no data for readIdleTimeout is abandoned by calling cancel.
+
+Parameters:
+  - ctx: Context of the request
+  - cancel: Cancels ctx, aborting the request
+  - body: The response body to read from
+  - progress: Progress display to report bytes to
+
+Returns:
+  - io.Reader: Reader that honors the rate limit and reports progress
+*/
+func (d *HTTPDownloader) wrapBody(ctx context.Context, cancel context.CancelCauseFunc, body io.Reader, progress *Progress) io.Reader {
+	body = &contextReader{ctx: ctx, r: body, idle: readIdleTimeout, cancel: cancel}
+	if d.Limiter != nil {
+		body = &RateLimitedReader{Reader: body, Limiter: d.Limiter, Context: ctx}
+	}
+	return &ProgressReader{Reader: body, Progress: progress}
+}
@@ -0,0 +1,458 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/*
+rangeServer serves data with HTTP range support, recording the Range header
+of every GET request.
+*/
+type rangeServer struct {
+	/* data is the file served */
+	data []byte
+
+	/* cut drops the connection after this many body bytes (0 sends all) */
+	cut int
+
+	/* ignoreRange answers range requests with the whole file and 200, and does not advertise Accept-Ranges */
+	ignoreRange bool
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+/*
+ServeHTTP implements http.Handler.
+*/
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.ignoreRange {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.data)))
+		return
+	}
+
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	body, status := s.data, http.StatusOK
+	if start, end, ok := parseRange(r.Header.Get("Range"), int64(len(s.data))); ok && !s.ignoreRange {
+		body, status = s.data[start:end+1], http.StatusPartialContent
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(s.data)))
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	if s.cut > 0 && s.cut < len(body) {
+		w.Write(body[:s.cut])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.Write(body)
+}
+
+/*
+requests returns the Range headers received so far.
+*/
+func (s *rangeServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.ranges)
+}
+
+/*
+testData returns n bytes that differ at every offset within a 251-byte
+cycle, so misplaced ranges are caught.
+*/
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+/*
+startServer starts an httptest server for s, stopped when the test ends.
+*/
+func startServer(t *testing.T, s *rangeServer) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+/*
+noRetryDelay removes the pause between chunk retries for the test.
+*/
+func noRetryDelay(t *testing.T) {
+	t.Helper()
+	delay := chunkRetryDelay
+	chunkRetryDelay = 0
+	t.Cleanup(func() { chunkRetryDelay = delay })
+}
+
+/*
+outputFile creates a preallocated output file of the given size.
+*/
+func outputFile(t *testing.T, size int) *os.File {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "ollama.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { out.Close() })
+	if err := out.Truncate(int64(size)); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+/*
+readFile returns the contents of out.
+*/
+func readFile(t *testing.T, out *os.File) []byte {
+	t.Helper()
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		n    int
+		want []chunk
+	}{
+		{
+			name: "single byte",
+			size: 1,
+			n:    4,
+			want: []chunk{{0, 0}},
+		},
+		{
+			name: "smaller than a chunk",
+			size: minChunkSize - 1,
+			n:    4,
+			want: []chunk{{0, minChunkSize - 2}},
+		},
+		{
+			name: "exactly one chunk",
+			size: minChunkSize,
+			n:    4,
+			want: []chunk{{0, minChunkSize - 1}},
+		},
+		{
+			name: "limited by the minimum chunk size",
+			size: 2*minChunkSize + 1,
+			n:    4,
+			want: []chunk{{0, minChunkSize}, {minChunkSize + 1, 2 * minChunkSize}},
+		},
+		{
+			name: "even split",
+			size: 4 * minChunkSize,
+			n:    4,
+			want: []chunk{
+				{0, minChunkSize - 1},
+				{minChunkSize, 2*minChunkSize - 1},
+				{2 * minChunkSize, 3*minChunkSize - 1},
+				{3 * minChunkSize, 4*minChunkSize - 1},
+			},
+		},
+		{
+			name: "short last chunk",
+			size: 4*minChunkSize + 2,
+			n:    4,
+			want: []chunk{
+				{0, minChunkSize},
+				{minChunkSize + 1, 2*minChunkSize + 1},
+				{2*minChunkSize + 2, 3*minChunkSize + 2},
+				{3*minChunkSize + 3, 4*minChunkSize + 1},
+			},
+		},
+		{
+			name: "one connection",
+			size: 4 * minChunkSize,
+			n:    1,
+			want: []chunk{{0, 4*minChunkSize - 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitChunks(tt.size, tt.n)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("splitChunks(%d, %d) = %v, want %v", tt.size, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchRange(t *testing.T) {
+	const size = 1000
+	data := testData(size)
+
+	tests := []struct {
+		name        string
+		server      *rangeServer
+		offset      int64
+		end         int64
+		wantWritten int64
+		wantErr     bool
+	}{
+		{
+			name:        "whole file",
+			server:      &rangeServer{data: data},
+			offset:      0,
+			end:         size - 1,
+			wantWritten: size,
+		},
+		{
+			name:        "middle",
+			server:      &rangeServer{data: data},
+			offset:      100,
+			end:         199,
+			wantWritten: 100,
+		},
+		{
+			name:        "last byte",
+			server:      &rangeServer{data: data},
+			offset:      size - 1,
+			end:         size - 1,
+			wantWritten: 1,
+		},
+		{
+			name:        "short read",
+			server:      &rangeServer{data: data, cut: 40},
+			offset:      100,
+			end:         199,
+			wantWritten: 40,
+			wantErr:     true,
+		},
+		{
+			name:        "server ignores Range",
+			server:      &rangeServer{data: data, ignoreRange: true},
+			offset:      100,
+			end:         199,
+			wantWritten: 0,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := startServer(t, tt.server)
+			out := outputFile(t, size)
+			d := &HTTPDownloader{Client: srv.Client()}
+
+			written, err := d.fetchRange(context.Background(), srv.URL, out, tt.offset, tt.end, NewProgress(size, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchRange() error = %v, want error %v", err, tt.wantErr)
+			}
+			if written != tt.wantWritten {
+				t.Fatalf("fetchRange() wrote %d bytes, want %d", written, tt.wantWritten)
+			}
+
+			/* Only the requested bytes may be written, at their own offsets */
+			want := make([]byte, size)
+			copy(want[tt.offset:], data[tt.offset:tt.offset+written])
+			if !bytes.Equal(readFile(t, out), want) {
+				t.Fatal("fetchRange() wrote the wrong bytes")
+			}
+		})
+	}
+}
+
+func TestDownloadChunk(t *testing.T) {
+	const size = 1000
+	data := testData(size)
+
+	tests := []struct {
+		name    string
+		mirrors []*rangeServer
+		chunk   chunk
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name:    "last chunk of one byte",
+			mirrors: []*rangeServer{{data: data}},
+			chunk:   chunk{size - 1, size - 1},
+			want:    [][]string{{"bytes=999-999"}},
+		},
+		{
+			name:    "resume after a short read",
+			mirrors: []*rangeServer{{data: data, cut: 60}},
+			chunk:   chunk{100, 199},
+			want:    [][]string{{"bytes=100-199", "bytes=160-199"}},
+		},
+		{
+			name:    "progress resets the failure count",
+			mirrors: []*rangeServer{{data: data, cut: 10}},
+			chunk:   chunk{0, 99},
+			want: [][]string{{
+				"bytes=0-99", "bytes=10-99", "bytes=20-99", "bytes=30-99", "bytes=40-99",
+				"bytes=50-99", "bytes=60-99", "bytes=70-99", "bytes=80-99", "bytes=90-99",
+			}},
+		},
+		{
+			name:    "switch mirrors mid-chunk",
+			mirrors: []*rangeServer{{data: data, cut: 30}, {data: data}},
+			chunk:   chunk{200, 399},
+			want:    [][]string{{"bytes=200-399"}, {"bytes=230-399"}},
+		},
+		{
+			name:    "server ignores Range",
+			mirrors: []*rangeServer{{data: data, ignoreRange: true}},
+			chunk:   chunk{100, 199},
+			want: [][]string{{
+				"bytes=100-199", "bytes=100-199", "bytes=100-199", "bytes=100-199",
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "switch away from a mirror that ignores Range",
+			mirrors: []*rangeServer{{data: data, ignoreRange: true}, {data: data}},
+			chunk:   chunk{100, 199},
+			want:    [][]string{{"bytes=100-199"}, {"bytes=100-199"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetryDelay(t)
+			var urls []string
+			for _, mirror := range tt.mirrors {
+				urls = append(urls, startServer(t, mirror).URL)
+			}
+			out := outputFile(t, size)
+			d := &HTTPDownloader{Client: http.DefaultClient}
+
+			err := d.downloadChunk(context.Background(), urls, out, tt.chunk, NewProgress(size, nil))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadChunk() error = %v, want error %v", err, tt.wantErr)
+			}
+			for i, mirror := range tt.mirrors {
+				if got := mirror.requests(); !slices.Equal(got, tt.want[i]) {
+					t.Errorf("mirror %d received %q, want %q", i, got, tt.want[i])
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want := make([]byte, size)
+			copy(want[tt.chunk.start:], data[tt.chunk.start:tt.chunk.end+1])
+			if !bytes.Equal(readFile(t, out), want) {
+				t.Fatal("downloadChunk() wrote the wrong bytes")
+			}
+		})
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	const size = 1000
+	data := testData(size)
+
+	tests := []struct {
+		name    string
+		mirrors []*rangeServer
+	}{
+		{
+			name:    "one mirror",
+			mirrors: []*rangeServer{{data: data}},
+		},
+		{
+			name:    "server ignores Range",
+			mirrors: []*rangeServer{{data: data, ignoreRange: true}},
+		},
+		{
+			name:    "first mirror drops the connection",
+			mirrors: []*rangeServer{{data: data, cut: 500}, {data: data}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noRetryDelay(t)
+			var urls []string
+			for _, mirror := range tt.mirrors {
+				urls = append(urls, startServer(t, mirror).URL)
+			}
+			path := filepath.Join(t.TempDir(), "ollama.tgz")
+			d := &HTTPDownloader{Client: http.DefaultClient, Connections: DefaultConnections}
+
+			if err := d.downloadFile(context.Background(), urls, path); err != nil {
+				t.Fatalf("downloadFile() = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("downloadFile() saved %d bytes that differ from the %d served", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestContextReaderIdle(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write(testData(10))
+		w.(http.Flusher).Flush()
+
+		/* Stall until the client gives up */
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := &contextReader{ctx: ctx, r: resp.Body, idle: 50 * time.Millisecond, cancel: cancel}
+	n, err := io.Copy(io.Discard, reader)
+	if n != 10 {
+		t.Errorf("read %d bytes before the stall, want 10", n)
+	}
+	if err == nil || !strings.Contains(err.Error(), "no data received") {
+		t.Fatalf("io.Copy() = %v, want an idle timeout error", err)
+	}
+	if !errors.Is(networkError(err), ErrNetwork) {
+		t.Fatalf("networkError(%v) is not ErrNetwork", err)
+	}
+}
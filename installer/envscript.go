@@ -0,0 +1,234 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+/* Base name of the env scripts, kept next to the user configuration file */
+const envScriptName = "env"
+
+/*
+EnvScriptPath returns where the env script for a shell is kept:
+~/.config/ollama-installer/env for POSIX shells (under $XDG_CONFIG_HOME if
+set), with a .fish, .csh or .nu extension for other shells.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: Path of the env script
+*/
+func (s Shell) EnvScriptPath(homeDir string) string {
+	name := envScriptName
+	switch s {
+	case ShellFish, ShellCsh, ShellNu:
+		name += "." + string(s)
+	case ShellTcsh:
+		/* tcsh shares the csh script */
+		name += "." + string(ShellCsh)
+	}
+	return filepath.Join(userConfigDir(homeDir), dataDirName, name)
+}
+
+/*
+EnvScript returns a script in the shell's syntax that puts binDir on PATH
+and exports the given OLLAMA_* settings. It is safe to source repeatedly:
+binDir is only added to PATH once.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - binDir: The directory to add to PATH
+  - env: Environment variables to export, by name
+
+Returns:
+  - string: The script
+*/
+func (s Shell) EnvScript(homeDir, binDir string, env map[string]string) string {
+	var script strings.Builder
+	script.WriteString("# Generated by ollama-installer; changes are overwritten on the next installation.\n")
+
+	dir := homePath(homeDir, binDir)
+	switch s {
+	case ShellFish:
+		/* fish_add_path does nothing if the directory is already on PATH */
+		fmt.Fprintf(&script, "fish_add_path -g %s\n", fishString(dir))
+	case ShellCsh, ShellTcsh:
+		fmt.Fprintf(&script, "if ( \":${PATH}:\" !~ *:%s:* ) setenv PATH \"%s:${PATH}\"\n", dir, dir)
+	case ShellNu:
+		fmt.Fprintf(&script, "$env.PATH = ($env.PATH | split row (char esep) | prepend %s | uniq)\n", nuPath(homeDir, binDir))
+	default:
+		fmt.Fprintf(&script, "case \":${PATH}:\" in\n    *:\"%s\":*) ;;\n    *) export PATH=\"%s:${PATH}\" ;;\nesac\n", dir, dir)
+	}
+	s.writeExports(&script, env)
+	return script.String()
+}
+
+/*
+ManualLines returns the lines a user who manages their own shell
+configuration should add to put binDir on PATH and export the given
+OLLAMA_* settings, without referring to the env script.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - binDir: The directory to add to PATH
+  - env: Environment variables to export, by name
+
+Returns:
+  - string: The lines, each ending in a newline
+*/
+func (s Shell) ManualLines(homeDir, binDir string, env map[string]string) string {
+	var lines strings.Builder
+	lines.WriteString(s.PathLine(homeDir, binDir) + "\n")
+	s.writeExports(&lines, env)
+	return lines.String()
+}
+
+/*
+writeExports writes a line exporting each variable in the shell's syntax,
+sorted by name.
+
+Parameters:
+  - w: The builder to write to
+  - env: Environment variables to export, by name
+*/
+func (s Shell) writeExports(w *strings.Builder, env map[string]string) {
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		value := env[name]
+		switch s {
+		case ShellFish:
+			fmt.Fprintf(w, "set -gx %s %s\n", name, shellQuote(value))
+		case ShellCsh, ShellTcsh:
+			fmt.Fprintf(w, "setenv %s %s\n", name, shellQuote(value))
+		case ShellNu:
+			fmt.Fprintf(w, "$env.%s = %s\n", name, nuString(value))
+		default:
+			fmt.Fprintf(w, "export %s=%s\n", name, shellQuote(value))
+		}
+	}
+}
+
+/*
+SourceLine returns the line that loads the env script at path in the
+shell's syntax. Paths in the home directory are written relative to it.
+The line does nothing if the script has been removed, except in nushell:
+nushell resolves `source` when parsing, so a missing script stops the whole
+file from loading. RemovePath therefore removes the line before the script.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - path: Path of the env script
+
+Returns:
+  - string: The line (e.g., `. "$HOME/.config/ollama-installer/env"`)
+*/
+func (s Shell) SourceLine(homeDir, path string) string {
+	switch s {
+	case ShellFish:
+		quoted := fishString(homePath(homeDir, path))
+		return fmt.Sprintf("test -f %s; and source %s", quoted, quoted)
+	case ShellCsh, ShellTcsh:
+		quoted := `"` + homePath(homeDir, path) + `"`
+		return fmt.Sprintf("if ( -f %s ) source %s", quoted, quoted)
+	case ShellNu:
+		/* nushell resolves sourced files when parsing, so the path is fixed */
+		if rel, inHome := homeRelative(homeDir, path); inHome {
+			return "source ~/" + filepath.ToSlash(rel)
+		}
+		return "source " + nuString(path)
+	default:
+		quoted := `"` + homePath(homeDir, path) + `"`
+		return fmt.Sprintf("if [ -f %s ]; then . %s; fi", quoted, quoted)
+	}
+}
+
+/*
+shellQuote quotes a value for POSIX shells, fish and csh with single quotes.
+
+Parameters:
+  - value: The value to quote
+
+Returns:
+  - string: The quoted value
+*/
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+/*
+fishString quotes a path for fish with double quotes, so that a leading
+$HOME is still expanded.
+
+Parameters:
+  - path: The path, possibly starting with $HOME
+
+Returns:
+  - string: The quoted path
+*/
+func fishString(path string) string {
+	return `"` + path + `"`
+}
+
+/*
+writeEnvScript writes the env script for a shell, reporting it to the
+observer as a modified file if its contents changed.
+
+Parameters:
+  - obs: Observer notified of the change (may be nil)
+  - shell: The user's shell family
+  - homeDir: The user's home directory path
+  - binDir: The directory to add to PATH
+  - env: Environment variables to export, by name
+
+Returns:
+  - string: Path of the script
+  - error: Any error writing the script
+*/
+func writeEnvScript(obs Observer, shell Shell, homeDir, binDir string, env map[string]string) (string, error) {
+	path := shell.EnvScriptPath(homeDir)
+	script := shell.EnvScript(homeDir, binDir, env)
+	if old, err := os.ReadFile(path); err == nil && string(old) == script {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), executableMode); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(script), configFileMode); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	notify(obs, Event{Kind: EventPathUpdated, Message: fmt.Sprintf("Wrote %s", path), Path: path})
+	return path, nil
+}
+
+/*
+removeEnvScripts deletes the env scripts of every shell.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - []string: The scripts that were removed
+  - error: Any error removing a script
+*/
+func removeEnvScripts(homeDir string) ([]string, error) {
+	var removed []string
+	for _, shell := range []Shell{ShellPOSIX, ShellFish, ShellCsh, ShellNu} {
+		path := shell.EnvScriptPath(homeDir)
+		err := os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
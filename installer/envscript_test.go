@@ -0,0 +1,47 @@
+package installer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteEnvScript(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	binDir := filepath.Join(home, "bin")
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantEvent bool
+	}{
+		{name: "new script", wantEvent: true},
+		{name: "unchanged script", wantEvent: false},
+		{name: "changed settings", env: map[string]string{"OLLAMA_HOST": "0.0.0.0:11434"}, wantEvent: true},
+		{name: "unchanged settings", env: map[string]string{"OLLAMA_HOST": "0.0.0.0:11434"}, wantEvent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []Event
+			obs := ObserverFunc(func(e Event) { events = append(events, e) })
+
+			path, err := writeEnvScript(obs, ShellBash, home, binDir, tt.env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readFileString(t, path); got != ShellBash.EnvScript(home, binDir, tt.env) {
+				t.Errorf("script = %q, want the bash env script", got)
+			}
+			if !tt.wantEvent {
+				if len(events) != 0 {
+					t.Errorf("events = %v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Kind != EventPathUpdated || events[0].Path != path {
+				t.Errorf("events = %v, want one %s event for %s", events, EventPathUpdated, path)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"runtime"
+	"strconv"
+	"syscall"
+)
+
+/*
+Sentinel errors that classify why an installation failed. Errors returned by
+the installer wrap one of these where the cause is known, so callers can
+test for them with errors.Is while the message still describes the details.
+*/
+var (
+	/* ErrRateLimited means a server refused the request because of rate limiting */
+	ErrRateLimited = errors.New("rate limited")
+
+	/* ErrNetwork means a request could not be completed or returned an error status */
+	ErrNetwork = errors.New("network error")
+
+	/* ErrAssetNotFound means the release, asset or checksum entry does not exist */
+	ErrAssetNotFound = errors.New("release asset not found")
+
+	/* ErrChecksumMismatch means the downloaded archive does not match its published SHA-256 */
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	/* ErrExtraction means the archive could not be unpacked or holds no usable binary */
+	ErrExtraction = errors.New("extraction failed")
+
+	/* ErrPermission means a file or directory could not be written due to permissions */
+	ErrPermission = errors.New("permission denied")
+
+	/* ErrDiskFull means a file could not be written because the disk or the user's quota is full */
+	ErrDiskFull = errors.New("no space left on device")
+
+	/* ErrPathUpdate means the binary was installed but PATH could not be updated */
+	ErrPathUpdate = errors.New("PATH update failed")
+)
+
+/*
+StatusError is returned when a server answers with an unexpected HTTP status.
+It matches ErrRateLimited, ErrAssetNotFound or ErrNetwork with errors.Is,
+depending on the status.
+*/
+type StatusError struct {
+	/* URL is the requested location */
+	URL string
+
+	/* StatusCode is the HTTP status returned */
+	StatusCode int
+
+	/* RateLimited is set when the response signals an exhausted rate limit */
+	RateLimited bool
+}
+
+/*
+newStatusError builds a StatusError from a response, detecting GitHub's
+rate limit responses (403 with no remaining requests) as well as 429.
+
+Parameters:
+  - url: The requested location
+  - resp: The response with the unexpected status
+
+Returns:
+  - *StatusError: The error describing the response
+*/
+func newStatusError(url string, resp *http.Response) *StatusError {
+	rateLimited := resp.StatusCode == http.StatusTooManyRequests
+	if resp.StatusCode == http.StatusForbidden {
+		remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+		rateLimited = err == nil && remaining == 0
+	}
+
+	return &StatusError{
+		URL:         url,
+		StatusCode:  resp.StatusCode,
+		RateLimited: rateLimited,
+	}
+}
+
+/*
+Error implements error.
+
+Returns:
+  - string: The URL and status, noting a rate limit
+*/
+func (e *StatusError) Error() string {
+	if e.RateLimited {
+		return fmt.Sprintf("%s returned status %d (rate limited)", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("%s returned status %d", e.URL, e.StatusCode)
+}
+
+/*
+Is reports whether the status falls into the class of target.
+
+Parameters:
+  - target: The sentinel error to compare with
+
+Returns:
+  - bool: true for ErrRateLimited, ErrAssetNotFound or ErrNetwork as the status warrants
+*/
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.RateLimited
+	case ErrAssetNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrNetwork:
+		return !e.RateLimited && e.StatusCode != http.StatusNotFound
+	}
+	return false
+}
+
+/*
+ChecksumError is returned when a file's SHA-256 differs from the expected
+digest. It matches ErrChecksumMismatch with errors.Is.
+*/
+type ChecksumError struct {
+	/* Path is the file that was hashed */
+	Path string
+
+	/* Got is the digest of the file */
+	Got string
+
+	/* Expected is the published digest */
+	Expected string
+}
+
+/*
+Error implements error.
+
+Returns:
+  - string: The file with its actual and expected digests
+*/
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: got %s, expected %s", e.Path, e.Got, e.Expected)
+}
+
+/*
+Is reports whether target is ErrChecksumMismatch.
+
+Parameters:
+  - target: The sentinel error to compare with
+
+Returns:
+  - bool: true if target is ErrChecksumMismatch
+*/
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksumMismatch
+}
+
+/*
+kindError attaches a sentinel class to an error without changing its message.
+Both the class and the original error are reachable with errors.Is and errors.As.
+*/
+type kindError struct {
+	kind error
+	err  error
+}
+
+/*
+Error implements error.
+
+Returns:
+  - string: The message of the classified error, unchanged
+*/
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+/*
+Unwrap exposes both the class and the classified error to errors.Is and
+errors.As.
+
+Returns:
+  - []error: The sentinel class and the original error
+*/
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+/*
+withKind classifies err as kind, leaving nil errors and errors that
+already match kind unchanged.
+
+Parameters:
+  - kind: One of the sentinel errors
+  - err: The error to classify
+
+Returns:
+  - error: err classified as kind
+*/
+func withKind(kind, err error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+/*
+fileError classifies a file system error, marking permission failures with
+ErrPermission, a full disk or quota with ErrDiskFull and everything else
+with fallback (if not nil).
+
+Parameters:
+  - err: The file system error
+  - fallback: Sentinel to use when the error is not a permission failure or full disk, or nil
+
+Returns:
+  - error: The classified error
+*/
+func fileError(err, fallback error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return withKind(ErrPermission, err)
+	}
+	if isDiskFull(err) {
+		if fallback != nil {
+			err = withKind(fallback, err)
+		}
+		return withKind(ErrDiskFull, err)
+	}
+	if fallback == nil {
+		return err
+	}
+	return withKind(fallback, err)
+}
+
+/*
+transferError classifies an error from copying a response body to a file:
+failures writing the file are file system errors, anything else is a
+network failure. Cancellation is left unclassified.
+
+Parameters:
+  - err: The error from the copy
+
+Returns:
+  - error: The classified error
+*/
+func transferError(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return fileError(err, nil)
+	}
+	return networkError(err)
+}
+
+/*
+networkError classifies a failed request as ErrNetwork. Cancellation is
+left unclassified so that it is not mistaken for a connectivity problem.
+
+Parameters:
+  - err: The error from the request
+
+Returns:
+  - error: The classified error
+*/
+func networkError(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return withKind(ErrNetwork, err)
+}
+
+/*
+isDiskFull reports whether err comes from a write that failed because the
+file system or the user's disk quota is full.
+
+Parameters:
+  - err: The file system error
+
+Returns:
+  - bool: true for ENOSPC and EDQUOT, or their Windows equivalents
+*/
+func isDiskFull(err error) bool {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT) {
+		return true
+	}
+
+	/* Windows reports ERROR_HANDLE_DISK_FULL (39) or ERROR_DISK_FULL (112) */
+	var errno syscall.Errno
+	return runtime.GOOS == "windows" && errors.As(err, &errno) && (errno == 39 || errno == 112)
+}
@@ -0,0 +1,80 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+)
+
+func TestFileError(t *testing.T) {
+	pathError := func(errno syscall.Errno) error {
+		return fmt.Errorf("failed to write binary: %w", &fs.PathError{Op: "write", Path: "/tmp/ollama", Err: errno})
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		fallback error
+		want     []error
+		notWant  []error
+	}{
+		{
+			name:    "disk full",
+			err:     pathError(syscall.ENOSPC),
+			want:    []error{ErrDiskFull, syscall.ENOSPC},
+			notWant: []error{ErrPermission},
+		},
+		{
+			name:    "quota exceeded",
+			err:     pathError(syscall.EDQUOT),
+			want:    []error{ErrDiskFull},
+			notWant: []error{ErrPermission},
+		},
+		{
+			name:     "disk full while extracting",
+			err:      pathError(syscall.ENOSPC),
+			fallback: ErrExtraction,
+			want:     []error{ErrDiskFull, ErrExtraction},
+		},
+		{
+			name:     "permission denied",
+			err:      pathError(syscall.EACCES),
+			fallback: ErrExtraction,
+			want:     []error{ErrPermission},
+			notWant:  []error{ErrDiskFull, ErrExtraction},
+		},
+		{
+			name:     "other error",
+			err:      pathError(syscall.EIO),
+			fallback: ErrExtraction,
+			want:     []error{ErrExtraction},
+			notWant:  []error{ErrDiskFull, ErrPermission},
+		},
+		{
+			name:    "other error without fallback",
+			err:     pathError(syscall.EIO),
+			notWant: []error{ErrDiskFull, ErrPermission, ErrExtraction},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fileError(tt.err, tt.fallback)
+			if got.Error() != tt.err.Error() {
+				t.Errorf("fileError() changed the message to %q", got)
+			}
+			for _, target := range tt.want {
+				if !errors.Is(got, target) {
+					t.Errorf("fileError() = %v, want it to match %v", got, target)
+				}
+			}
+			for _, target := range tt.notWant {
+				if errors.Is(got, target) {
+					t.Errorf("fileError() = %v, want it not to match %v", got, target)
+				}
+			}
+		})
+	}
+}
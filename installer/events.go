@@ -0,0 +1,139 @@
+package installer
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+EventKind identifies a step of the installation lifecycle.
+*/
+type EventKind string
+
+const (
+	/* EventResolveStarted is sent before looking up the version to install */
+	EventResolveStarted EventKind = "resolve_started"
+
+	/* EventResolved is sent once the version to install is known */
+	EventResolved EventKind = "resolved"
+
+	/* EventDownloadStarted is sent when a download attempt begins */
+	EventDownloadStarted EventKind = "download_started"
+
+	/* EventDownloadProgress reports bytes downloaded so far, at most every few hundred milliseconds */
+	EventDownloadProgress EventKind = "download_progress"
+
+	/* EventDownloadFinished is sent when a download attempt ends, successfully or not */
+	EventDownloadFinished EventKind = "download_finished"
+
+	/* EventVerify is sent when a checksum or the installed binary has been verified */
+	EventVerify EventKind = "verify"
+
+	/* EventExtract is sent before unpacking the downloaded archive */
+	EventExtract EventKind = "extract"
+
+	/* EventInstall is sent once the binary is in its final location */
+	EventInstall EventKind = "install"
+
+	/* EventPathUpdated is sent after a shell configuration or PATH change */
+	EventPathUpdated EventKind = "path_updated"
+
+	/* EventInfo carries an informational message that fits no other step */
+	EventInfo EventKind = "info"
+
+	/* EventWarning carries a problem that did not stop the installation */
+	EventWarning EventKind = "warning"
+
+	/* EventDone is sent when the installation completed successfully */
+	EventDone EventKind = "done"
+
+	/* EventFailed is sent when the installation stopped with an error */
+	EventFailed EventKind = "failed"
+)
+
+/*
+Event describes something that happened during installation. Message is
+always a human-readable summary; the remaining fields are filled in when
+they apply to the event kind.
+*/
+type Event struct {
+	Kind    EventKind
+	Time    time.Time
+	Message string
+
+	/* Version is the release tag being installed */
+	Version string
+
+	/* URL is the download location of the asset */
+	URL string
+
+	/* Path is the file the event refers to (archive, binary or shell file) */
+	Path string
+
+	/* Checksum is the SHA-256 of the downloaded asset */
+	Checksum string
+
+	/* BytesDone and BytesTotal report download progress (BytesTotal <= 0 if unknown) */
+	BytesDone  int64
+	BytesTotal int64
+
+	/* Elapsed is the time since the current download started */
+	Elapsed time.Duration
+
+	/* Diff is a unified diff of the change made to the shell file at Path */
+	Diff string
+
+	/* Err is the cause of an EventFailed or EventWarning, or a PATH update failure on EventDone */
+	Err error
+}
+
+/*
+Observer receives installation events. Implementations must be safe for
+concurrent use because parallel download chunks report independently.
+*/
+type Observer interface {
+	OnEvent(Event)
+}
+
+/*
+ObserverFunc adapts a function to the Observer interface.
+*/
+type ObserverFunc func(Event)
+
+/*
+OnEvent implements Observer by calling the function.
+*/
+func (f ObserverFunc) OnEvent(e Event) {
+	f(e)
+}
+
+/*
+notify stamps an event with the current time and delivers it to an observer.
+A nil observer discards the event.
+
+Parameters:
+  - obs: The observer to notify (may be nil)
+  - e: The event to send
+*/
+func notify(obs Observer, e Event) {
+	if obs == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	obs.OnEvent(e)
+}
+
+/*
+notifyf sends an event of the given kind with a formatted message.
+
+Parameters:
+  - obs: The observer to notify (may be nil)
+  - kind: The event kind
+  - format: fmt.Sprintf format for the message
+  - args: Arguments for the format
+*/
+func notifyf(obs Observer, kind EventKind, format string, args ...any) {
+	notify(obs, Event{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,224 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Extractor unpacks a downloaded release archive.
+*/
+type Extractor interface {
+	/*
+		Extract unpacks archivePath into destDir and returns the path of the
+		binary named binaryName inside it.
+	*/
+	Extract(ctx context.Context, archivePath, destDir, binaryName string) (string, error)
+}
+
+/*
+ArchiveExtractor is the default Extractor. It handles the ZIP archives used
+for Windows and macOS and the gzipped tarballs used for Linux, choosing the
+format from the archive's file extension.
+*/
+type ArchiveExtractor struct{}
+
+/*
+Extract implements Extractor.
+
+Parameters:
+  - ctx: Context for cancellation
+  - archivePath: Path to the downloaded archive
+  - destDir: Directory to extract to
+  - binaryName: Name of the binary to find
+
+Returns:
+  - string: Path to the extracted binary
+  - error: Any error that occurred during extraction
+*/
+func (ArchiveExtractor) Extract(ctx context.Context, archivePath, destDir, binaryName string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if strings.HasSuffix(archivePath, ".zip") {
+		return extractZip(ctx, archivePath, destDir, binaryName)
+	}
+	return extractTarGz(ctx, archivePath, destDir)
+}
+
+/*
+extractZip extracts a ZIP archive and returns the path to the binary.
+This is used for Windows and macOS downloads.
+
+Parameters:
+  - ctx: Context for cancellation, checked before each entry and while copying
+  - archivePath: Path to the ZIP file
+  - tempDir: Directory to extract to
+  - binaryName: Name of the binary to find
+
+Returns:
+  - string: Path to the extracted binary
+  - error: Any error that occurred during extraction
+*/
+func extractZip(ctx context.Context, archivePath, tempDir, binaryName string) (string, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer reader.Close()
+
+	var binaryPath string
+
+	for _, file := range reader.File {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		/* Create the file path */
+		path := filepath.Join(tempDir, file.Name)
+
+		/* Ensure we don't extract outside of tempDir */
+		if !strings.HasPrefix(path, filepath.Clean(tempDir)+string(os.PathSeparator)) {
+			continue
+		}
+
+		if file.FileInfo().IsDir() {
+			os.MkdirAll(path, file.FileInfo().Mode())
+			continue
+		}
+
+		/* Create parent directories */
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		/* Extract file */
+		fileReader, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open file in zip: %w", err)
+		}
+
+		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
+		if err != nil {
+			fileReader.Close()
+			return "", fmt.Errorf("failed to create target file: %w", err)
+		}
+
+		_, err = io.Copy(targetFile, &contextReader{ctx: ctx, r: fileReader})
+		fileReader.Close()
+		targetFile.Close()
+
+		if err != nil {
+			return "", fmt.Errorf("failed to copy file: %w", err)
+		}
+
+		/* Check if this is the binary we're looking for */
+		if filepath.Base(path) == binaryName {
+			binaryPath = path
+		}
+	}
+
+	if binaryPath == "" {
+		return "", fmt.Errorf("binary %s not found in zip archive", binaryName)
+	}
+
+	return binaryPath, nil
+}
+
+/*
+extractTarGz extracts a tar.gz archive and returns the path to the binary.
+This is used for Linux downloads. Uses pure Go implementation.
+
+Parameters:
+  - ctx: Context for cancellation, checked before each entry and while copying
+  - archivePath: Path to the tar.gz file
+  - tempDir: Directory to extract to
+
+Returns:
+  - string: Path to the extracted binary
+  - error: Any error that occurred during extraction
+*/
+func extractTarGz(ctx context.Context, archivePath, tempDir string) (string, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tar.gz file: %w", err)
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	var binaryPath string
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		// Create the file path
+		path := filepath.Join(tempDir, header.Name)
+
+		// Ensure we don't extract outside of tempDir (security check)
+		if !strings.HasPrefix(path, filepath.Clean(tempDir)+string(os.PathSeparator)) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			// Create directory
+			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
+				return "", fmt.Errorf("failed to create directory %s: %w", path, err)
+			}
+
+		case tar.TypeReg:
+			// Create parent directories
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return "", fmt.Errorf("failed to create parent directory: %w", err)
+			}
+
+			// Create and write file
+			outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return "", fmt.Errorf("failed to create file %s: %w", path, err)
+			}
+
+			_, err = io.Copy(outFile, &contextReader{ctx: ctx, r: tarReader})
+			outFile.Close()
+
+			if err != nil {
+				return "", fmt.Errorf("failed to write file %s: %w", path, err)
+			}
+
+			// Check if this is the binary we're looking for
+			if strings.HasSuffix(path, "/bin/ollama") || filepath.Base(path) == "ollama" {
+				binaryPath = path
+			}
+		}
+	}
+
+	if binaryPath == "" {
+		return "", fmt.Errorf("ollama binary not found in tar.gz archive")
+	}
+
+	return binaryPath, nil
+}
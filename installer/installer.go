@@ -0,0 +1,1007 @@
+/*
+Package installer downloads Ollama releases and installs them for the
+current user. It is the library behind the ollama-installer command and
+can be embedded in other tools: create an Installer with New and call
+Install, observing progress through an Observer.
+
+Each stage of the installation is an interface (ReleaseSource, Downloader,
+Extractor and PathUpdater) with a default implementation, so callers can
+replace individual steps while keeping the rest of the process.
+*/
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	/* File permissions */
+	executableMode = 0755
+	configFileMode = 0644
+
+	/* HTTP timeouts (downloads have no overall limit, see NewHTTPClient) */
+	httpTimeout         = 30 * time.Second
+	dialTimeout         = 30 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+	readIdleTimeout     = 30 * time.Second
+
+	/* Temporary directory name */
+	tempDirName = "ollama-extract"
+
+	/* Suffix for the copy of the previous binary kept for rollback */
+	backupSuffix = ".previous"
+
+	/* Suffix for the binary while it is being copied into place */
+	partialSuffix = ".partial"
+)
+
+/*
+Options configures an Installer. The zero value installs the latest
+release from GitHub into the current user's home directory using the
+default implementation of every stage.
+*/
+type Options struct {
+	/* Version is the release tag to install; empty means the latest release */
+	Version string
+
+	/* Mirrors lists base URLs to download release assets from (default GitHub) */
+	Mirrors []string
+
+	/* Connections is the number of concurrent range requests (default 4) */
+	Connections int
+
+	/* LimitRate caps the download speed in bytes per second (0 is unlimited) */
+	LimitRate int64
+
+	/* ServeCheck starts `ollama serve` after installing to verify it responds */
+	ServeCheck bool
+
+	/*
+		Checksum pins the SHA-256 the asset must match instead of using the
+		published one, e.g. from a lockfile
+	*/
+	Checksum string
+
+	/*
+		TrustMirrorChecksums lets the default Source take the checksum from
+		a mirror's sha256sum.txt when GitHub does not provide it, e.g. on
+		networks that cannot reach GitHub
+	*/
+	TrustMirrorChecksums bool
+
+	/* Token is an optional GitHub token for API requests made by the default Source */
+	Token string
+
+	/* HomeDir is the home directory to install into (default os.UserHomeDir) */
+	HomeDir string
+
+	/* InstallDir is the directory the binary is installed to (default BinDir(HomeDir)) */
+	InstallDir string
+
+	/* PathDir is the directory added to PATH (default InstallDir) */
+	PathDir string
+
+	/*
+		Versioned installs into the version's own directory under
+		VersionsDir(HomeDir) instead of InstallDir, for use with the shim;
+		PathDir then defaults to BinDir(HomeDir)
+	*/
+	Versioned bool
+
+	/*
+		ShimTarget is the ollama-installer executable run by the version shim.
+		When set with Versioned, the shim is installed as the binary in
+		BinDir(HomeDir) and the installed version becomes the default
+	*/
+	ShimTarget string
+
+	/*
+		Archive is a release asset that was already downloaded (e.g. with
+		Fetch) to install instead of downloading one. It is verified against
+		the checksum like a download and is left in place afterwards
+	*/
+	Archive string
+
+	/*
+		NoModifyPath leaves shell configuration files alone: the default
+		PathUpdater reports the lines to add by hand instead
+	*/
+	NoModifyPath bool
+
+	/*
+		Profile is the shell configuration file the default PathUpdater
+		edits instead of the shell's own startup files
+	*/
+	Profile string
+
+	/* Env holds OLLAMA_* variables the default PathUpdater exports from the env script */
+	Env map[string]string
+
+	/*
+		CI is the CI system to install for (see DetectCI). When set, the
+		default PathUpdater is a CIPathUpdater and the version and paths are
+		written to the system's step outputs
+	*/
+	CI *CI
+
+	/* ExportFile is a dotenv file to write the installed version and paths to (default: CI.ExportFile) */
+	ExportFile string
+
+	/*
+		Root is the root file system of another system to install into, such
+		as a container image or chroot being built. HomeDir, InstallDir,
+		PathDir and the default PathUpdater's Profile are taken inside it,
+		no shell is started to check PATH and the installed binary is
+		neither run nor checked against this machine's architecture. Paths
+		taken from variables such as XDG_CONFIG_HOME are not mapped, so
+		callers should unset them
+	*/
+	Root string
+
+	/* Observer receives progress and lifecycle events (may be nil) */
+	Observer Observer
+
+	/* Source resolves versions and checksums (default GitHubSource) */
+	Source ReleaseSource
+
+	/* Downloader fetches the release asset (default HTTPDownloader) */
+	Downloader Downloader
+
+	/* Extractor unpacks the release asset (default ArchiveExtractor) */
+	Extractor Extractor
+
+	/* PathUpdater adds the install directory to PATH (default ShellPathUpdater) */
+	PathUpdater PathUpdater
+}
+
+/*
+Result describes a completed installation.
+*/
+type Result struct {
+	/* Version is the release tag that was installed */
+	Version string
+
+	/* BinaryPath is the location of the installed binary */
+	BinaryPath string
+
+	/* PathDir is the directory that puts ollama on PATH (the shim's with Versioned) */
+	PathDir string
+
+	/*
+		PathErr is set when the binary was installed but PATH could not be
+		updated; it matches ErrPathUpdate with errors.Is
+	*/
+	PathErr error
+}
+
+/*
+Installer installs Ollama according to its Options.
+*/
+type Installer struct {
+	opts    Options
+	homeDir string
+	client  *http.Client
+}
+
+/* Platform-specific configuration */
+type PlatformConfig struct {
+	assetName    string
+	tempFileName string
+	installPath  string
+	binaryName   string
+}
+
+/*
+New creates an Installer, filling in defaults for any options left unset.
+
+Parameters:
+  - opts: Installer configuration
+
+Returns:
+  - *Installer: The configured installer
+  - error: An error if the home directory cannot be determined
+*/
+func New(opts Options) (*Installer, error) {
+	homeDir := opts.HomeDir
+	if homeDir == "" {
+		var err error
+		if homeDir, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+	}
+	if opts.Root != "" {
+		homeDir = rootPath(opts.Root, homeDir)
+		opts.InstallDir = rootPath(opts.Root, opts.InstallDir)
+		opts.PathDir = rootPath(opts.Root, opts.PathDir)
+		opts.Profile = rootPath(opts.Root, opts.Profile)
+	}
+
+	if len(opts.Mirrors) == 0 {
+		opts.Mirrors = []string{GitHubDownloadURL}
+	}
+	if opts.Connections <= 0 {
+		opts.Connections = DefaultConnections
+	}
+
+	client := NewHTTPClient()
+	if opts.Source == nil {
+		opts.Source = &GitHubSource{
+			Client:          client,
+			Mirrors:         opts.Mirrors,
+			MirrorChecksums: opts.TrustMirrorChecksums,
+			Token:           opts.Token,
+		}
+	}
+	if opts.Downloader == nil {
+		d := &HTTPDownloader{
+			Client:      client,
+			Connections: opts.Connections,
+			Observer:    opts.Observer,
+		}
+		if opts.LimitRate > 0 {
+			d.Limiter = NewRateLimiter(opts.LimitRate)
+		}
+		opts.Downloader = d
+	}
+	if opts.Extractor == nil {
+		opts.Extractor = ArchiveExtractor{}
+	}
+	if opts.PathUpdater == nil && opts.CI != nil {
+		opts.PathUpdater = &CIPathUpdater{CI: *opts.CI, Observer: opts.Observer}
+	}
+	if opts.PathUpdater == nil {
+		opts.PathUpdater = &ShellPathUpdater{
+			HomeDir:  homeDir,
+			Root:     opts.Root,
+			Profile:  opts.Profile,
+			Env:      opts.Env,
+			NoModify: opts.NoModifyPath,
+			Observer: opts.Observer,
+		}
+	}
+
+	return &Installer{opts: opts, homeDir: homeDir, client: client}, nil
+}
+
+/*
+Install resolves the version to install and installs it, reporting every
+step to the observer. The final outcome is always reported as either an
+EventDone or an EventFailed event.
+
+Cancelling ctx stops the installation at the next step boundary or read;
+temporary files are removed and any previous binary is restored, and the
+returned error matches context.Canceled.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - Result: The installed version and binary location
+  - error: Any error that stopped the installation
+*/
+func (i *Installer) Install(ctx context.Context) (Result, error) {
+	obs := i.opts.Observer
+
+	version, err := i.resolveVersion(ctx)
+	if err != nil {
+		notify(obs, Event{Kind: EventFailed, Message: fmt.Sprintf("Error getting latest version: %v", err), Err: err})
+		return Result{}, err
+	}
+
+	result, err := i.install(ctx, version)
+	if err != nil {
+		message := fmt.Sprintf("Installation failed: %v", err)
+		if errors.Is(err, context.Canceled) {
+			message = "Installation cancelled; the previous installation was left in place"
+		}
+		notify(obs, Event{Kind: EventFailed, Message: message, Version: version, Err: err})
+		return Result{}, err
+	}
+
+	notify(obs, Event{
+		Kind:    EventDone,
+		Message: fmt.Sprintf("Ollama installed successfully to %s", result.BinaryPath),
+		Version: version,
+		Path:    result.BinaryPath,
+		Err:     result.PathErr,
+	})
+	return result, nil
+}
+
+/*
+resolveVersion reports the platform and determines the version to install,
+looking up the latest release unless a version was configured.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - string: The version tag to install
+  - error: Any error looking up the latest release
+*/
+func (i *Installer) resolveVersion(ctx context.Context) (string, error) {
+	obs := i.opts.Observer
+	notifyf(obs, EventInfo, "Detected platform: %s/%s", runtime.GOOS, runtime.GOARCH)
+
+	version := i.opts.Version
+	if version == "" {
+		notify(obs, Event{Kind: EventResolveStarted})
+
+		var err error
+		if version, err = i.opts.Source.LatestVersion(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	url := getDownloadURL(i.opts.Mirrors[0], version)
+	notify(obs, Event{
+		Kind:    EventResolved,
+		Message: fmt.Sprintf("Ollama version: %s\nDownload URL: %s", version, url),
+		Version: version,
+		URL:     url,
+	})
+	return version, nil
+}
+
+/*
+Releases lists every published release, oldest first, if the configured
+Source implements ReleaseLister.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - []GitHubRelease: The releases
+  - error: Any error listing the releases, or if the Source cannot list them
+*/
+func (i *Installer) Releases(ctx context.Context) ([]GitHubRelease, error) {
+	lister, ok := i.opts.Source.(ReleaseLister)
+	if !ok {
+		return nil, errors.New("the release source cannot list releases")
+	}
+	return lister.ListReleases(ctx)
+}
+
+/*
+checksum returns the SHA-256 the asset must match: the pinned
+Options.Checksum if set, otherwise the one published for the release.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - version: The version tag being installed
+  - config: Platform-specific configuration
+
+Returns:
+  - string: The lowercase hex SHA-256
+  - error: Any error looking up the published checksum
+*/
+func (i *Installer) checksum(ctx context.Context, version string, config PlatformConfig) (string, error) {
+	if i.opts.Checksum != "" {
+		return strings.ToLower(i.opts.Checksum), nil
+	}
+
+	checksum, err := i.opts.Source.Checksum(ctx, version, config.assetName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get checksum: %w", err)
+	}
+	return checksum, nil
+}
+
+/*
+installPaths returns the directory the binary is installed to and the
+binary's full path, honoring Options.InstallDir and Options.Versioned.
+
+Parameters:
+  - config: Platform-specific configuration
+  - version: The version tag being installed
+
+Returns:
+  - string: The installation directory
+  - string: The path of the installed binary
+*/
+func (i *Installer) installPaths(config PlatformConfig, version string) (string, string) {
+	binDir := i.opts.InstallDir
+	switch {
+	case i.opts.Versioned:
+		binDir = filepath.Join(VersionsDir(i.homeDir), version)
+	case binDir == "":
+		binDir = BinDir(i.homeDir)
+	}
+	return binDir, filepath.Join(binDir, config.binaryName)
+}
+
+/*
+pathDir returns the directory to add to PATH for an installation directory.
+
+Parameters:
+  - binDir: The installation directory
+
+Returns:
+  - string: Options.PathDir if set, otherwise binDir (BinDir for versioned installs)
+*/
+func (i *Installer) pathDir(binDir string) string {
+	switch {
+	case i.opts.PathDir != "":
+		return i.opts.PathDir
+	case i.opts.Versioned:
+		return BinDir(i.homeDir)
+	}
+	return binDir
+}
+
+/*
+rootPath maps a path on the target system to its location under root.
+
+Parameters:
+  - root: The target system's root directory
+  - path: The absolute path on the target system, or empty
+
+Returns:
+  - string: The path under root (empty if path is empty)
+*/
+func rootPath(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(root, path)
+}
+
+/*
+BinDir returns the default installation directory for the current platform:
+the standard Ollama location on Windows and ~/bin elsewhere.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: The installation directory
+*/
+func BinDir(homeDir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(homeDir, "AppData", "Local", "Programs", "Ollama")
+	}
+	return filepath.Join(homeDir, "bin")
+}
+
+/*
+AssetName returns the name of the release asset for the current platform
+(e.g., "ollama-linux-amd64.tgz"), as saved by Fetch.
+
+Returns:
+  - string: The asset file name
+*/
+func AssetName() string {
+	return getPlatformConfig().assetName
+}
+
+/*
+getPlatformConfig returns the platform-specific configuration based on the current OS.
+
+Returns:
+  - PlatformConfig: Configuration struct with platform-specific settings
+*/
+func getPlatformConfig() PlatformConfig {
+	return platformConfig(runtime.GOOS, runtime.GOARCH)
+}
+
+/*
+platformConfig returns the configuration for a platform.
+It determines the appropriate release asset, file extensions, and paths
+for Windows and Linux platforms. Linux and Windows have an asset per
+architecture, amd64 unless arm64 is asked for; macOS has a universal one.
+
+Parameters:
+  - goos: The operating system, as in runtime.GOOS
+  - goarch: The architecture, as in runtime.GOARCH
+
+Returns:
+  - PlatformConfig: Configuration struct with platform-specific settings
+*/
+func platformConfig(goos, goarch string) PlatformConfig {
+	arch := "amd64"
+	if goarch == "arm64" {
+		arch = "arm64"
+	}
+
+	switch goos {
+	case "windows":
+		return PlatformConfig{
+			assetName:    "ollama-windows-" + arch + ".zip",
+			tempFileName: "ollama.zip",
+			installPath:  "~/AppData/Local/Programs/Ollama/ollama.exe",
+			binaryName:   "ollama.exe",
+		}
+	case "linux":
+		return PlatformConfig{
+			assetName:    "ollama-linux-" + arch + ".tgz",
+			tempFileName: "ollama.tgz",
+			installPath:  "~/bin/ollama",
+			binaryName:   "ollama",
+		}
+	case "darwin":
+		return PlatformConfig{
+			assetName:    "ollama-darwin.zip",
+			tempFileName: "ollama.zip",
+			installPath:  "~/bin/ollama",
+			binaryName:   "ollama",
+		}
+	default:
+		// Default to Linux
+		return PlatformConfig{
+			assetName:    "ollama-linux-" + arch + ".tgz",
+			tempFileName: "ollama.tgz",
+			installPath:  "~/bin/ollama",
+			binaryName:   "ollama",
+		}
+	}
+}
+
+/*
+getDownloadURL constructs the download URL for a specific Ollama version.
+Mirrors use the same layout as GitHub releases, so the URL is the mirror
+base followed by the version tag and the platform's asset name.
+
+Parameters:
+  - mirror: The mirror base URL (e.g., GitHubDownloadURL)
+  - version: The version tag (e.g., "v0.1.20")
+
+Returns:
+  - string: The complete download URL for the current platform
+*/
+func getDownloadURL(mirror, version string) string {
+	config := getPlatformConfig()
+	return releaseFileURL(mirror, version, config.assetName)
+}
+
+/*
+install downloads and installs a specific Ollama version to the user's bin
+directory. It performs the complete installation process including:
+  - Looking up the published SHA-256 of the release asset
+  - Ranking the configured mirrors and downloading from the fastest one,
+    failing over to the others when needed
+  - Verifying the downloaded archive against the published checksum
+  - Creating the ~/bin directory if it doesn't exist
+  - Extracting and installing the binary
+  - Making the binary executable
+  - Running the installed binary to verify it reports the expected version,
+    restoring the previous binary if it does not
+  - Installing the version shim, for versioned installs with a ShimTarget
+  - Updating shell configuration files to include ~/bin in PATH
+  - Cleaning up temporary files
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - version: The version tag being installed (e.g., "v0.1.20")
+
+Returns:
+  - Result: The installed binary and any PATH update failure
+  - error: Any error that occurred during the installation process
+*/
+func (i *Installer) install(ctx context.Context, version string) (Result, error) {
+	config := getPlatformConfig()
+	homeDir := i.homeDir
+	obs := i.opts.Observer
+
+	tempFile := i.opts.Archive
+	if tempFile == "" {
+		tempFile = filepath.Join(homeDir, config.tempFileName)
+
+		/* Ensure cleanup of temporary files */
+		defer func() {
+			os.Remove(tempFile)
+		}()
+
+		if err := i.download(ctx, version, config, tempFile); err != nil {
+			return Result{}, err
+		}
+	} else if err := i.verifyArchive(ctx, version, config); err != nil {
+		return Result{}, err
+	}
+
+	/* For all platforms, use the extraction method */
+	tempDir := filepath.Join(homeDir, tempDirName)
+
+	/* Determine the installation directory based on platform */
+	binDir, finalPath := i.installPaths(config, version)
+
+	/* Ensure cleanup of temporary directory */
+	defer func() {
+		os.RemoveAll(tempDir)
+	}()
+
+	/* Create the bin directory if it doesn't exist */
+	if err := os.MkdirAll(binDir, executableMode); err != nil {
+		return Result{}, fileError(fmt.Errorf("failed to create bin directory: %w", err), nil)
+	}
+
+	/* Keep the previous binary so a broken install can be rolled back */
+	backupPath, err := backupBinary(finalPath)
+	if err != nil {
+		return Result{}, fileError(fmt.Errorf("failed to back up existing binary: %w", err), nil)
+	}
+
+	/* Extract and install the binary */
+	if err := i.extractAndInstall(ctx, tempFile, tempDir, finalPath, config); err != nil {
+		if rbErr := restoreBinary(finalPath, backupPath); rbErr != nil {
+			notify(obs, Event{Kind: EventWarning, Message: fmt.Sprintf("Failed to restore previous binary: %v", rbErr), Err: rbErr})
+		}
+		return Result{}, fmt.Errorf("failed to extract and install: %w", err)
+	}
+
+	/*
+		Make sure the installed binary actually runs; cancelling up to here
+		also rolls back. A binary under Root belongs to another system and
+		may not run here, so it is not started
+	*/
+	if i.opts.Root != "" {
+		notifyf(obs, EventInfo, "Skipped running %s --version, as it is installed for another system", finalPath)
+		err = nil
+	} else {
+		err = smokeTest(ctx, finalPath, version, i.opts.ServeCheck, obs)
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	} else if err != nil {
+		err = fmt.Errorf("smoke test failed: %w", err)
+	}
+	if err != nil {
+		if rbErr := restoreBinary(finalPath, backupPath); rbErr != nil {
+			return Result{}, fmt.Errorf("%w (rollback also failed: %v)", err, rbErr)
+		}
+		if backupPath != "" {
+			notify(obs, Event{Kind: EventInfo, Message: fmt.Sprintf("Restored previous binary at %s", finalPath), Path: finalPath})
+		}
+		return Result{}, err
+	}
+
+	if backupPath != "" {
+		os.Remove(backupPath)
+	}
+
+	result := Result{Version: version, BinaryPath: finalPath, PathDir: i.pathDir(binDir)}
+
+	/* Point the shim at the versioned install */
+	if i.opts.Versioned && i.opts.ShimTarget != "" {
+		shimPath, err := InstallShim(BinDir(homeDir), i.opts.ShimTarget)
+		if err == nil {
+			err = SetDefaultVersion(homeDir, version)
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		notify(obs, Event{
+			Kind:    EventInstall,
+			Message: fmt.Sprintf("Installed version shim to %s (default version %s)", shimPath, version),
+			Version: version,
+			Path:    shimPath,
+		})
+	}
+
+	/*
+		Update PATH in shell configuration (skip for Windows as it uses
+		standard location); CI systems need it on every platform
+	*/
+	if runtime.GOOS != "windows" || i.opts.CI != nil {
+		if err := i.opts.PathUpdater.UpdatePath(ctx, result.PathDir); err != nil {
+			result.PathErr = withKind(ErrPathUpdate, err)
+			notify(obs, Event{Kind: EventWarning, Message: fmt.Sprintf("Failed to update PATH: %v", err), Err: result.PathErr})
+		}
+	} else {
+		notifyf(obs, EventInfo, "Using standard Windows Ollama location (already in PATH)")
+	}
+
+	if err := i.exportResult(result); err != nil {
+		if result.PathErr == nil {
+			result.PathErr = err
+		}
+		notify(obs, Event{Kind: EventWarning, Message: fmt.Sprintf("Failed to export the installation: %v", err), Err: err})
+	}
+
+	return result, nil
+}
+
+/*
+download fetches the release asset from the fastest mirror to dest,
+verifying it against the expected checksum.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - version: The version tag being downloaded
+  - config: Platform-specific configuration
+  - dest: Path to save the asset to
+
+Returns:
+  - error: Any error looking up the checksum or downloading the asset
+*/
+func (i *Installer) download(ctx context.Context, version string, config PlatformConfig, dest string) error {
+	/* Resolve the expected checksum before trusting any mirror */
+	checksum, err := i.checksum(ctx, version, config)
+	if err != nil {
+		return err
+	}
+
+	urls := make([]string, len(i.opts.Mirrors))
+	for n, mirror := range i.opts.Mirrors {
+		urls[n] = getDownloadURL(mirror, version)
+	}
+	if err := i.opts.Downloader.Download(ctx, urls, dest, checksum); err != nil {
+		return fmt.Errorf("failed to download Ollama: %w", err)
+	}
+	return nil
+}
+
+/*
+verifyArchive checks Options.Archive against the expected checksum.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - version: The version tag being installed
+  - config: Platform-specific configuration
+
+Returns:
+  - error: Any error looking up the checksum, or a ChecksumError on mismatch
+*/
+func (i *Installer) verifyArchive(ctx context.Context, version string, config PlatformConfig) error {
+	checksum, err := i.checksum(ctx, version, config)
+	if err != nil {
+		return err
+	}
+
+	notify(i.opts.Observer, Event{Kind: EventVerify, Message: fmt.Sprintf("Verifying %s...", i.opts.Archive), Path: i.opts.Archive})
+	if err := verifyChecksum(i.opts.Archive, checksum); err != nil {
+		return fileError(err, nil)
+	}
+	return nil
+}
+
+/*
+Fetch resolves the version to install and downloads its release asset
+into dir without installing it. The asset can later be installed by
+setting Options.Archive.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - dir: Directory to save the asset in (created if needed)
+
+Returns:
+  - string: Path of the downloaded asset
+  - error: Any error resolving the version or downloading the asset
+*/
+func (i *Installer) Fetch(ctx context.Context, dir string) (string, error) {
+	config := getPlatformConfig()
+
+	version, err := i.resolveVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, executableMode); err != nil {
+		return "", fileError(fmt.Errorf("failed to create download directory: %w", err), nil)
+	}
+
+	dest := filepath.Join(dir, config.assetName)
+	if err := i.download(ctx, version, config, dest); err != nil {
+		notify(i.opts.Observer, Event{Kind: EventFailed, Message: fmt.Sprintf("Download failed: %v", err), Version: version, Err: err})
+		return "", err
+	}
+	return dest, nil
+}
+
+/*
+extractAndInstall extracts the downloaded archive and installs the binary.
+It performs the following steps:
+  - Creates a temporary extraction directory
+  - Extracts the archive with the configured Extractor
+  - Validates that the extracted binary matches the host OS and architecture
+  - Copies the extracted binary to the final installation path
+  - Sets executable permissions on the binary
+
+Parameters:
+  - ctx: Context for cancellation
+  - archivePath: Path to the downloaded archive
+  - tempDir: Temporary directory for extraction
+  - finalPath: Final installation path for the binary
+  - config: Platform-specific configuration
+
+Returns:
+  - error: Any error that occurred during extraction or installation
+*/
+func (i *Installer) extractAndInstall(ctx context.Context, archivePath, tempDir, finalPath string, config PlatformConfig) error {
+	obs := i.opts.Observer
+
+	/* Clean up and create temporary extraction directory */
+	if err := os.RemoveAll(tempDir); err != nil {
+		return fileError(fmt.Errorf("failed to remove existing temp directory: %w", err), nil)
+	}
+
+	if err := os.MkdirAll(tempDir, executableMode); err != nil {
+		return fileError(fmt.Errorf("failed to create temp directory: %w", err), nil)
+	}
+
+	notify(obs, Event{Kind: EventExtract, Message: "Extracting Ollama binary...", Path: archivePath})
+
+	sourcePath, err := i.opts.Extractor.Extract(ctx, archivePath, tempDir, config.binaryName)
+	if err != nil {
+		return fileError(fmt.Errorf("extraction failed: %w", err), ErrExtraction)
+	}
+
+	/*
+		Refuse to install a binary that cannot run on this machine. Under
+		Root it runs on another system, which may be a different platform
+		(e.g. an arm64 image built on amd64), so only check that it is a
+		complete executable
+	*/
+	if i.opts.Root != "" {
+		_, err = inspectBinary(sourcePath)
+	} else {
+		err = validateBinary(sourcePath, runtime.GOOS, runtime.GOARCH)
+	}
+	if err != nil {
+		return withKind(ErrExtraction, fmt.Errorf("invalid binary: %w", err))
+	}
+
+	/* Copy the extracted binary to the final location */
+	if err := copyFile(ctx, sourcePath, finalPath); err != nil {
+		return fileError(fmt.Errorf("failed to copy binary: %w", err), nil)
+	}
+
+	/* Make it executable */
+	if err := os.Chmod(finalPath, executableMode); err != nil {
+		return fileError(fmt.Errorf("failed to make binary executable: %w", err), nil)
+	}
+
+	notify(obs, Event{Kind: EventInstall, Message: fmt.Sprintf("Installed binary to %s", finalPath), Path: finalPath})
+	return nil
+}
+
+/*
+backupBinary moves an existing binary aside so it can be restored if the
+new installation fails. The backup is stored next to the binary with the
+backupSuffix appended.
+
+Parameters:
+  - binaryPath: Path to the installed binary
+
+Returns:
+  - string: Path to the backup, or an empty string if there was no binary
+  - error: Any error that occurred while moving the binary
+*/
+func backupBinary(binaryPath string) (string, error) {
+	if !fileExists(binaryPath) {
+		return "", nil
+	}
+
+	backupPath := binaryPath + backupSuffix
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove old backup: %w", err)
+	}
+
+	if err := os.Rename(binaryPath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to move %s aside: %w", binaryPath, err)
+	}
+
+	return backupPath, nil
+}
+
+/*
+restoreBinary undoes a failed installation by removing the new binary and
+moving the backup, if any, back into place.
+
+Parameters:
+  - binaryPath: Path to the installed binary
+  - backupPath: Path returned by backupBinary (may be empty)
+
+Returns:
+  - error: Any error that occurred while restoring the binary
+*/
+func restoreBinary(binaryPath, backupPath string) error {
+	if err := os.Remove(binaryPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove new binary: %w", err)
+	}
+
+	if backupPath == "" {
+		return nil
+	}
+
+	if err := os.Rename(backupPath, binaryPath); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", backupPath, err)
+	}
+
+	return nil
+}
+
+/*
+copyFile copies a file from source to destination. The data is written to
+a temporary file next to dst that is renamed into place only once the copy
+is complete, so an interrupted copy never leaves a partial file at dst.
+
+Parameters:
+  - ctx: Context for cancellation, checked while copying
+  - src: Source file path
+  - dst: Destination file path
+
+Returns:
+  - error: Any error that occurred during copying
+*/
+func copyFile(ctx context.Context, src, dst string) error {
+	sourceFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer sourceFile.Close()
+
+	partialPath := dst + partialSuffix
+	destFile, err := os.Create(partialPath)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer os.Remove(partialPath)
+
+	_, err = io.Copy(destFile, &contextReader{ctx: ctx, r: sourceFile})
+	if closeErr := destFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := os.Rename(partialPath, dst); err != nil {
+		return fmt.Errorf("failed to move binary into place: %w", err)
+	}
+
+	return nil
+}
+
+/*
+contextReader wraps an io.Reader so that long copies stop promptly once
+the context is cancelled. With an idle timeout it also gives up on a
+stalled transfer: a Read that blocks for longer calls cancel, which must
+cancel ctx and with it the request being read.
+*/
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+
+	/* idle is the longest a single Read may block (0 for no limit) */
+	idle time.Duration
+
+	/* cancel cancels the request when a Read exceeds idle */
+	cancel context.CancelCauseFunc
+
+	timer *time.Timer
+}
+
+/*
+Read implements io.Reader, failing with the context's error after
+cancellation, or with an idle timeout error if no data arrived in time.
+*/
+func (c *contextReader) Read(p []byte) (int, error) {
+	if c.ctx.Err() != nil {
+		return 0, context.Cause(c.ctx)
+	}
+	if c.idle <= 0 {
+		return c.r.Read(p)
+	}
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.idle, func() {
+			c.cancel(fmt.Errorf("no data received for %s", c.idle))
+		})
+	} else {
+		c.timer.Reset(c.idle)
+	}
+	n, err := c.r.Read(p)
+	if !c.timer.Stop() && err != nil {
+		err = context.Cause(c.ctx)
+	}
+	return n, err
+}
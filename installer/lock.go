@@ -0,0 +1,171 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+/* Default name of the lockfile, kept in the project directory */
+const LockFileName = "ollama-installer.lock"
+
+/* Platforms recorded in a lockfile, as GOOS/GOARCH */
+var lockPlatforms = []string{
+	"darwin/amd64",
+	"darwin/arm64",
+	"linux/amd64",
+	"linux/arm64",
+	"windows/amd64",
+	"windows/arm64",
+}
+
+/*
+Lock pins a release and the SHA-256 of its asset for every platform, so
+that every machine installing from it gets byte-identical builds.
+*/
+type Lock struct {
+	/* Version is the locked release tag */
+	Version string `json:"version"`
+
+	/* Platforms maps a platform, as GOOS/GOARCH (e.g. "linux/arm64"), to its asset */
+	Platforms map[string]LockedAsset `json:"platforms"`
+}
+
+/*
+LockedAsset is the release asset locked for one platform.
+*/
+type LockedAsset struct {
+	/* Asset is the release file name */
+	Asset string `json:"asset"`
+
+	/* URL is where the asset was resolved from */
+	URL string `json:"url"`
+
+	/* SHA256 is the lowercase hex digest the asset must match */
+	SHA256 string `json:"sha256"`
+}
+
+/*
+Lock resolves the version to install and the published checksum of its
+asset on every platform, without downloading the assets. Platforms the
+release has no asset for are left out.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - Lock: The resolved lock
+  - error: Any error resolving the version or a checksum
+*/
+func (i *Installer) Lock(ctx context.Context) (Lock, error) {
+	version, err := i.resolveVersion(ctx)
+	if err != nil {
+		return Lock{}, err
+	}
+
+	lock := Lock{Version: version, Platforms: make(map[string]LockedAsset, len(lockPlatforms))}
+	checksums := map[string]string{}
+	for _, platform := range lockPlatforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		config := platformConfig(goos, goarch)
+
+		/* Platforms may share an asset, such as the universal macOS build */
+		checksum, ok := checksums[config.assetName]
+		if !ok {
+			checksum, err = i.opts.Source.Checksum(ctx, version, config.assetName)
+			if errors.Is(err, ErrAssetNotFound) {
+				/* Older releases lack some platforms, e.g. Windows on arm64 */
+				notifyf(i.opts.Observer, EventWarning, "%s has no %s, so %s is not locked", version, config.assetName, platform)
+				continue
+			}
+			if err != nil {
+				return Lock{}, fmt.Errorf("failed to get checksum for %s: %w", config.assetName, err)
+			}
+			checksums[config.assetName] = checksum
+		}
+		lock.Platforms[platform] = LockedAsset{
+			Asset:  config.assetName,
+			URL:    releaseFileURL(i.opts.Mirrors[0], version, config.assetName),
+			SHA256: checksum,
+		}
+	}
+	if len(lock.Platforms) == 0 {
+		return Lock{}, fmt.Errorf("%s publishes no checksums for any platform: %w", version, ErrAssetNotFound)
+	}
+	return lock, nil
+}
+
+/*
+ReadLock reads a lockfile.
+
+Parameters:
+  - path: Path of the lockfile
+
+Returns:
+  - Lock: The lock
+  - error: Any error reading or parsing the file, or if it locks no
+    release tag
+*/
+func ReadLock(path string) (Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Lock{}, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return Lock{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if lock.Version == "" {
+		return Lock{}, fmt.Errorf("%s does not lock a version", path)
+	}
+	if err := ValidateVersion(lock.Version); err != nil {
+		return Lock{}, fmt.Errorf("invalid version in %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+/*
+Write saves the lock as indented JSON.
+
+Parameters:
+  - path: Path of the lockfile
+
+Returns:
+  - error: Any error encoding or writing the file
+*/
+func (l Lock) Write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), configFileMode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+/*
+Current returns the asset locked for the running platform, checking that
+it is the asset this platform installs.
+
+Returns:
+  - LockedAsset: The locked asset
+  - error: ErrAssetNotFound if the lock has no entry for this platform or
+    locks a different asset for it
+*/
+func (l Lock) Current() (LockedAsset, error) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	asset, ok := l.Platforms[platform]
+	if !ok || asset.SHA256 == "" {
+		return LockedAsset{}, fmt.Errorf("lockfile has no entry for %s: %w", platform, ErrAssetNotFound)
+	}
+	if want := AssetName(); asset.Asset != want {
+		return LockedAsset{}, fmt.Errorf("lockfile locks %s for %s, but this platform installs %s: %w", asset.Asset, platform, want, ErrAssetNotFound)
+	}
+	return asset, nil
+}
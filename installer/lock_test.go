@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLockCurrent(t *testing.T) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	sum := "cf9a2a55e92cd8bf23f7a0c07671fe2a5e85f09bbe9bb24a3520d8814fd33576"
+
+	tests := []struct {
+		name      string
+		platforms map[string]LockedAsset
+		wantErr   bool
+	}{
+		{
+			name:      "entry for this platform",
+			platforms: map[string]LockedAsset{platform: {Asset: AssetName(), SHA256: sum}},
+		},
+		{
+			name:      "no entry for this platform",
+			platforms: map[string]LockedAsset{"plan9/mips": {Asset: AssetName(), SHA256: sum}},
+			wantErr:   true,
+		},
+		{
+			name:      "entry keyed by operating system only",
+			platforms: map[string]LockedAsset{runtime.GOOS: {Asset: AssetName(), SHA256: sum}},
+			wantErr:   true,
+		},
+		{
+			name:      "another platform's asset",
+			platforms: map[string]LockedAsset{platform: {Asset: "ollama-other.tgz", SHA256: sum}},
+			wantErr:   true,
+		},
+		{
+			name:      "no checksum",
+			platforms: map[string]LockedAsset{platform: {Asset: AssetName()}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock := Lock{Version: "v0.5.7", Platforms: tt.platforms}
+			asset, err := lock.Current()
+			if tt.wantErr {
+				if !errors.Is(err, ErrAssetNotFound) {
+					t.Fatalf("Current() = %v, want ErrAssetNotFound", err)
+				}
+				return
+			}
+			if err != nil || asset.SHA256 != sum {
+				t.Fatalf("Current() = %+v, %v, want the locked asset", asset, err)
+			}
+		})
+	}
+}
+
+func TestReadLockVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "release tag", content: `{"version": "v0.5.7", "platforms": {}}`},
+		{name: "no version", content: `{"platforms": {}}`, wantErr: true},
+		{name: "path traversal", content: `{"version": "v../../../tmp/x", "platforms": {}}`, wantErr: true},
+		{name: "malformed", content: `{"version": `, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ollama-installer.lock")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadLock(path); (err != nil) != tt.wantErr {
+				t.Errorf("ReadLock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
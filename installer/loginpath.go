@@ -0,0 +1,165 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	/* Time allowed for a login shell to start and print its PATH */
+	loginShellTimeout = 5 * time.Second
+
+	/* Prefix marking the PATH in a login shell's output, which startup files may add to */
+	loginPathMarker = "__ollama_installer_path__"
+
+	/* PATH given to the login shell, which its startup files normally replace */
+	loginShellBasePath = "/usr/bin:/bin"
+)
+
+/* Variables passed on to the login shell; everything else starts fresh */
+var loginShellEnv = []string{"USER", "LOGNAME", "LANG", "XDG_CONFIG_HOME", "ZDOTDIR"}
+
+/*
+loginShellPath starts a fresh login shell, with a minimal environment and
+a timeout, and returns the PATH it ends up with once the system and user
+startup files (/etc/profile, /etc/profile.d, ~/.profile and so on) have
+run. This is the PATH a new terminal or login session will have.
+
+Parameters:
+  - ctx: Context for cancellation
+  - shellPath: Name or path of the user's shell
+  - homeDir: The user's home directory path
+
+Returns:
+  - []string: The PATH entries, in order
+  - error: Any error starting the shell or finding its PATH in the output
+*/
+func loginShellPath(ctx context.Context, shellPath, homeDir string) ([]string, error) {
+	if shellPath == "" {
+		return nil, fmt.Errorf("the login shell is unknown")
+	}
+	executable, err := exec.LookPath(shellPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", shellPath, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loginShellTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, loginShellArgs(ParseShell(shellPath))...)
+	cmd.Env = []string{"HOME=" + homeDir, "SHELL=" + executable, "TERM=dumb", "PATH=" + loginShellBasePath}
+	for _, name := range loginShellEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			cmd.Env = append(cmd.Env, name+"="+value)
+		}
+	}
+	cmd.Dir = homeDir
+
+	/* Don't wait for background processes the startup files left holding stdout */
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s did not start within %s", filepath.Base(executable), loginShellTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", filepath.Base(executable), err)
+	}
+
+	for _, line := range splitLines(string(output)) {
+		if value, ok := strings.CutPrefix(line, loginPathMarker); ok {
+			return filepath.SplitList(value), nil
+		}
+	}
+	return nil, fmt.Errorf("%s did not report its PATH", filepath.Base(executable))
+}
+
+/*
+loginShellArgs returns the arguments that make a shell start as a login
+shell and print its PATH after loginPathMarker.
+
+Parameters:
+  - shell: The shell family
+
+Returns:
+  - []string: The arguments
+*/
+func loginShellArgs(shell Shell) []string {
+	switch shell {
+	case ShellFish:
+		return []string{"-l", "-c", `printf '%s%s\n' ` + loginPathMarker + ` (string join : $PATH)`}
+	case ShellNu:
+		return []string{"-l", "-c", `print ("` + loginPathMarker + `" + ($env.PATH | str join (char esep)))`}
+	case ShellCsh, ShellTcsh:
+		/* csh cannot combine -l with -c; it still reads .cshrc or .tcshrc */
+		return []string{"-c", `printf '%s%s\n' ` + loginPathMarker + ` "$PATH"`}
+	default:
+		return []string{"-l", "-c", `printf '%s%s\n' ` + loginPathMarker + ` "$PATH"`}
+	}
+}
+
+/*
+pathIndex returns the position of a directory in PATH entries.
+
+Parameters:
+  - entries: The PATH entries
+  - dir: The directory
+
+Returns:
+  - int: The index of the first matching entry, or -1 if dir is not on PATH
+*/
+func pathIndex(entries []string, dir string) int {
+	dir = filepath.Clean(dir)
+	for n, entry := range entries {
+		if entry != "" && filepath.Clean(entry) == dir {
+			return n
+		}
+	}
+	return -1
+}
+
+/*
+shadowingBinaries lists the executables named binaryName in PATH entries
+that come before dir (or anywhere, if dir is not on PATH), which a shell
+would run instead of the one in dir. Links to the binary in dir are not
+counted.
+
+Parameters:
+  - entries: The PATH entries
+  - dir: The directory holding the newly installed binary
+  - binaryName: The binary's file name
+
+Returns:
+  - []string: Paths of the shadowing executables, in PATH order
+*/
+func shadowingBinaries(entries []string, dir, binaryName string) []string {
+	if n := pathIndex(entries, dir); n >= 0 {
+		entries = entries[:n]
+	}
+	installed, _ := os.Stat(filepath.Join(dir, binaryName))
+
+	var shadows []string
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+		path := filepath.Join(entry, binaryName)
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		if installed != nil && os.SameFile(info, installed) {
+			continue
+		}
+		if !slices.Contains(shadows, path) {
+			shadows = append(shadows, path)
+		}
+	}
+	return shadows
+}
@@ -0,0 +1,313 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	/* Default download location for release assets */
+	GitHubDownloadURL = "https://github.com/ollama/ollama/releases/download"
+
+	/* Alias accepted by --mirror for the GitHub download location */
+	githubMirrorAlias = "github"
+
+	/* Timeout for the HEAD request used to rank each mirror */
+	mirrorProbeTimeout = 5 * time.Second
+)
+
+/*
+mirrorProbe is the outcome of probing one mirror for an asset.
+*/
+type mirrorProbe struct {
+	url     string
+	latency time.Duration
+	err     error
+}
+
+/*
+ParseMirror normalizes a --mirror value into a base URL. It accepts
+http(s) and file URLs, the alias "github", and plain filesystem paths,
+which are converted to file URLs.
+
+Parameters:
+  - value: The mirror as given on the command line
+
+Returns:
+  - string: The base URL of the mirror without a trailing slash
+  - error: An error if the value cannot be used as a mirror
+*/
+func ParseMirror(value string) (string, error) {
+	if value == githubMirrorAlias {
+		return GitHubDownloadURL, nil
+	}
+
+	if u, err := url.Parse(value); err == nil && len(u.Scheme) > 1 {
+		switch u.Scheme {
+		case "http", "https", "file":
+			return strings.TrimSuffix(value, "/"), nil
+		default:
+			return "", fmt.Errorf("unsupported mirror scheme %q", u.Scheme)
+		}
+	}
+
+	path, err := filepath.Abs(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid mirror path %s: %w", value, err)
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path /* Windows drive letter paths */
+	}
+	return strings.TrimSuffix((&url.URL{Scheme: "file", Path: path}).String(), "/"), nil
+}
+
+/*
+releaseFileURL returns the URL of a file belonging to a release on a mirror.
+Mirrors follow the GitHub layout of <base>/<version tag>/<file name>.
+
+Parameters:
+  - mirror: The mirror base URL
+  - version: The version tag (e.g., "v0.1.20")
+  - name: The file name within the release
+
+Returns:
+  - string: The full URL of the file
+*/
+func releaseFileURL(mirror, version, name string) string {
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(mirror, "/"), version, name)
+}
+
+/*
+NewHTTPClient returns an HTTP client that also understands file:// URLs,
+so local mirrors can be used through the same download code.
+The client has no overall timeout, which would cut off large downloads on
+slow links; instead connecting, the TLS handshake and waiting for response
+headers are limited, and downloads stop when the body stalls.
+
+Returns:
+  - *http.Client: Client with connection timeouts and file support
+*/
+func NewHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = httpTimeout
+	transport.RegisterProtocol("file", fileTransport{})
+
+	return &http.Client{Transport: transport}
+}
+
+/*
+rankMirrors probes every mirror for the asset with a HEAD request and
+returns the reachable ones ordered from fastest to slowest. Unreachable
+mirrors are kept at the end in their original order so they can still be
+tried if everything else fails.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - client: HTTP client to use
+  - urls: Asset URLs on each mirror, in configured order
+  - obs: Observer to report probe results to (may be nil)
+
+Returns:
+  - []string: The asset URLs in the order they should be tried
+*/
+func rankMirrors(ctx context.Context, client *http.Client, urls []string, obs Observer) []string {
+	if len(urls) < 2 {
+		return urls
+	}
+
+	probes := make([]mirrorProbe, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			probes[i] = probeMirror(ctx, client, u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	sort.SliceStable(probes, func(i, j int) bool {
+		if (probes[i].err == nil) != (probes[j].err == nil) {
+			return probes[i].err == nil
+		}
+		return probes[i].err == nil && probes[i].latency < probes[j].latency
+	})
+
+	ranked := make([]string, len(probes))
+	for i, probe := range probes {
+		if probe.err != nil {
+			notify(obs, Event{
+				Kind:    EventWarning,
+				Message: fmt.Sprintf("Mirror %s unreachable: %v", probe.url, probe.err),
+				URL:     probe.url,
+				Err:     probe.err,
+			})
+		} else {
+			notifyf(obs, EventInfo, "Mirror %s responded in %s", probe.url, probe.latency.Round(time.Millisecond))
+		}
+		ranked[i] = probe.url
+	}
+	return ranked
+}
+
+/*
+probeMirror times a HEAD request for an asset on one mirror.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - client: HTTP client to use
+  - assetURL: The asset URL on the mirror
+
+Returns:
+  - mirrorProbe: The URL with its latency or the error encountered
+*/
+func probeMirror(ctx context.Context, client *http.Client, assetURL string) mirrorProbe {
+	ctx, cancel := context.WithTimeout(ctx, mirrorProbeTimeout)
+	defer cancel()
+
+	probe := mirrorProbe{url: assetURL}
+
+	req, err := http.NewRequestWithContext(ctx, "HEAD", assetURL, nil)
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		probe.err = err
+		return probe
+	}
+	resp.Body.Close()
+	probe.latency = time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		probe.err = fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return probe
+}
+
+/*
+fileTransport serves file:// URLs from the local filesystem. It supports
+HEAD requests and single byte ranges so local mirrors behave like an HTTP
+server that advertises "Accept-Ranges: bytes".
+*/
+type fileTransport struct{}
+
+/*
+RoundTrip implements http.RoundTripper for file:// URLs.
+*/
+func (fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := req.URL.Path
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+
+	file, err := os.Open(filepath.FromSlash(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fileResponse(req, http.StatusNotFound, http.NoBody, 0), nil
+		}
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return fileResponse(req, http.StatusNotFound, http.NoBody, 0), nil
+	}
+	size := stat.Size()
+
+	if req.Method == "HEAD" {
+		file.Close()
+		return fileResponse(req, http.StatusOK, http.NoBody, size), nil
+	}
+
+	start, end, ok := parseRange(req.Header.Get("Range"), size)
+	if !ok {
+		return fileResponse(req, http.StatusOK, file, size), nil
+	}
+
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, start, end-start+1), file}
+	resp := fileResponse(req, http.StatusPartialContent, body, end-start+1)
+	resp.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	return resp, nil
+}
+
+/*
+fileResponse builds the http.Response returned by fileTransport.
+*/
+func fileResponse(req *http.Request, status int, body io.ReadCloser, length int64) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Accept-Ranges": {"bytes"}},
+		Body:          body,
+		ContentLength: length,
+		Request:       req,
+	}
+}
+
+/*
+parseRange parses a single "bytes=start-end" Range header value.
+
+Parameters:
+  - header: The Range header value
+  - size: Size of the file in bytes
+
+Returns:
+  - int64: First byte of the range
+  - int64: Last byte of the range (inclusive)
+  - bool: false if the header is absent or not a satisfiable single range
+*/
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+
+	return start, end, true
+}
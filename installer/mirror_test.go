@@ -0,0 +1,38 @@
+package installer
+
+import "testing"
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		size      int64
+		wantStart int64
+		wantEnd   int64
+		wantOK    bool
+	}{
+		{"bytes=0-99", 1000, 0, 99, true},
+		{"bytes=100-199", 1000, 100, 199, true},
+		{"bytes=999-999", 1000, 999, 999, true},
+		{"bytes=500-", 1000, 500, 999, true},
+		{"bytes=900-2000", 1000, 900, 999, true},
+		{"bytes=0-0", 1, 0, 0, true},
+		{"bytes=1000-", 1000, 0, 0, false},
+		{"bytes=200-100", 1000, 0, 0, false},
+		{"bytes=-100", 1000, 0, 0, false},
+		{"bytes=0-9,20-29", 1000, 0, 0, false},
+		{"bytes=abc-def", 1000, 0, 0, false},
+		{"bytes=10", 1000, 0, 0, false},
+		{"items=0-99", 1000, 0, 0, false},
+		{"", 1000, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, end, ok := parseRange(tt.header, tt.size)
+			if start != tt.wantStart || end != tt.wantEnd || ok != tt.wantOK {
+				t.Fatalf("parseRange(%q, %d) = %d, %d, %v, want %d, %d, %v",
+					tt.header, tt.size, start, end, ok, tt.wantStart, tt.wantEnd, tt.wantOK)
+			}
+		})
+	}
+}
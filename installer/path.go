@@ -0,0 +1,677 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+/*
+PathUpdater makes an installation directory available on the user's PATH.
+*/
+type PathUpdater interface {
+	UpdatePath(ctx context.Context, binDir string) error
+}
+
+/*
+PathPlanner is implemented by PathUpdaters that can describe the change
+they would make without making it. Installer.Plan uses it for dry runs.
+*/
+type PathPlanner interface {
+	PlanPath(ctx context.Context, binDir string) (PathChange, error)
+}
+
+/*
+PathChange describes the edit needed to put a directory on PATH.
+*/
+type PathChange struct {
+	/*
+		File is the shell configuration file to edit, empty for the Windows
+		user PATH and when a login shell already has the directory on PATH
+	*/
+	File string
+
+	/* Line is the line appended to File, or the directory added to the Windows user PATH */
+	Line string
+
+	/* Script is the env script that Line loads, rewritten whenever its contents change (empty if none) */
+	Script string
+
+	/* Present is set when PATH already includes the directory and nothing would change */
+	Present bool
+
+	/* Update is set when File has a managed block with a different line that would be replaced */
+	Update bool
+
+	/* Manual is set when File is left for the user to edit, with Line holding the lines to add */
+	Manual bool
+}
+
+/*
+ShellPathUpdater is the default PathUpdater. On Unix systems it edits the
+user's shell configuration files; on Windows it updates the user PATH
+environment variable.
+*/
+type ShellPathUpdater struct {
+	/* HomeDir is the home directory containing the shell configuration files */
+	HomeDir string
+
+	/*
+		Root is the root file system HomeDir is in when installing for another
+		system; the shell is then taken from its /etc/passwd and no login
+		shell is started
+	*/
+	Root string
+
+	/* Shell is the name or path of the user's shell (default DetectShell) */
+	Shell string
+
+	/*
+		Profile is the configuration file to edit instead of the shell's
+		startup files; it is created if it does not exist
+	*/
+	Profile string
+
+	/* Env holds OLLAMA_* variables to export from the env script, by name */
+	Env map[string]string
+
+	/*
+		NoModify leaves shell configuration files and the env script alone;
+		the lines to add by hand are reported instead
+	*/
+	NoModify bool
+
+	/* Observer receives EventPathUpdated events (may be nil) */
+	Observer Observer
+}
+
+/*
+UpdatePath adds binDir to PATH based on the operating system.
+For Windows, it updates the user PATH environment variable using PowerShell.
+For Unix systems, it updates shell configuration files unless a fresh login
+shell already has binDir on PATH, or with NoModify reports the lines to add
+instead, and then warns about any other ollama that comes first on the
+login shell's PATH (or this process's, if the login shell's is unknown).
+
+Parameters:
+  - ctx: Context for cancellation
+  - binDir: The directory to add to PATH
+
+Returns:
+  - error: Any error that occurred during the PATH update process
+*/
+func (u *ShellPathUpdater) UpdatePath(ctx context.Context, binDir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		if u.NoModify {
+			notifyf(u.Observer, EventInfo, "The user PATH was not modified; add %s to it to run ollama from any terminal", binDir)
+			return nil
+		}
+		return updateWindowsPath(binDir, u.Observer)
+	}
+	loginPath := u.loginPath(ctx)
+	changed, err := u.updateUnixPath(binDir, loginPath)
+	if err != nil {
+		return err
+	}
+	if u.Root != "" {
+		if dir, err := filepath.Rel(u.Root, binDir); err == nil {
+			notifyf(u.Observer, EventInfo, "Containers started without a login shell do not read shell configuration; set PATH in the image as well (e.g. ENV PATH=%s:$PATH in a Dockerfile)", filepath.Join("/", dir))
+		}
+	} else if changed && pathIndex(filepath.SplitList(os.Getenv("PATH")), binDir) < 0 {
+		shell := u.shell()
+		notifyf(u.Observer, EventInfo, "To use ollama in this terminal now, run: %s", shell.SourceLine(u.HomeDir, shell.EnvScriptPath(u.HomeDir)))
+	}
+
+	/*
+		Check shadowing with the PATH new shells will have. If the login
+		shell's PATH is unknown, fall back to this process's, with binDir in
+		front if the env script is now loaded, as the script puts it there
+	*/
+	entries := loginPath
+	switch {
+	case changed && loginPath != nil:
+		entries = u.loginPath(ctx)
+	case loginPath == nil && u.Root == "":
+		entries = filepath.SplitList(os.Getenv("PATH"))
+		if changed && pathIndex(entries, binDir) < 0 {
+			entries = append([]string{binDir}, entries...)
+		}
+	}
+	for _, shadow := range shadowingBinaries(entries, binDir, getPlatformConfig().binaryName) {
+		notify(u.Observer, Event{
+			Kind:    EventWarning,
+			Message: fmt.Sprintf("%s comes before %s on PATH and will run instead of the version just installed; remove it or move %s to the front of PATH", shadow, binDir, binDir),
+			Path:    shadow,
+		})
+	}
+	return nil
+}
+
+/*
+PlanPath implements PathPlanner, reporting what UpdatePath would change.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binDir: The directory to add to PATH
+
+Returns:
+  - PathChange: The file and line that would be modified
+  - error: Any error that occurred while inspecting the configuration
+*/
+func (u *ShellPathUpdater) PlanPath(ctx context.Context, binDir string) (PathChange, error) {
+	if err := ctx.Err(); err != nil {
+		return PathChange{}, err
+	}
+
+	if runtime.GOOS == "windows" {
+		return PathChange{Line: binDir, Present: strings.Contains(os.Getenv("PATH"), binDir)}, nil
+	}
+	return u.planUnixPath(binDir, u.loginPath(ctx)), nil
+}
+
+/*
+shellPath returns the configured or detected shell.
+
+Returns:
+  - string: Name or path of the shell
+*/
+func (u *ShellPathUpdater) shellPath() string {
+	switch {
+	case u.Shell != "":
+		return u.Shell
+	case u.Root != "":
+		return rootShell(u.Root, u.HomeDir)
+	}
+	return detectShellPath()
+}
+
+/*
+shell returns the family of the configured or detected shell.
+
+Returns:
+  - Shell: The shell family
+*/
+func (u *ShellPathUpdater) shell() Shell {
+	return ParseShell(u.shellPath())
+}
+
+/*
+loginPath returns the PATH of a fresh login shell (see loginShellPath),
+reporting to the observer if it cannot be determined. There is none when
+installing under Root.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - []string: The PATH entries, or nil if unknown
+*/
+func (u *ShellPathUpdater) loginPath(ctx context.Context) []string {
+	/* The other system's shell and startup files cannot run here */
+	if u.Root != "" {
+		return nil
+	}
+	entries, err := loginShellPath(ctx, u.shellPath(), u.HomeDir)
+	if err != nil {
+		notifyf(u.Observer, EventInfo, "Could not check the PATH of a login shell: %v", err)
+		return nil
+	}
+	return entries
+}
+
+/*
+startupFiles returns the files to consider for the PATH line: Profile if
+set, otherwise the files the shell reads at startup.
+
+Parameters:
+  - shell: The user's shell family
+
+Returns:
+  - []startupFile: The files, in order of preference
+*/
+func (u *ShellPathUpdater) startupFiles(shell Shell) []startupFile {
+	if u.Profile != "" {
+		return []startupFile{{path: u.Profile, create: true}}
+	}
+	return shell.startupFiles(u.HomeDir)
+}
+
+/*
+updateWindowsPath adds a directory to the Windows user PATH environment variable.
+It uses PowerShell to safely update the PATH without truncation issues.
+
+Parameters:
+  - binDir: The directory to add to PATH
+  - obs: Observer to report PATH changes to (may be nil)
+
+Returns:
+  - error: Any error that occurred during the PATH update process
+*/
+func updateWindowsPath(binDir string, obs Observer) error {
+	// Check if already in PATH
+	currentPath := os.Getenv("PATH")
+	if strings.Contains(currentPath, binDir) {
+		notifyf(obs, EventInfo, "PATH already contains %s", binDir)
+		return nil
+	}
+
+	// Use PowerShell to safely update the user PATH environment variable
+	psScript := fmt.Sprintf(`
+$currentPath = [Environment]::GetEnvironmentVariable('PATH', 'User')
+$newPath = '%s'
+if ($currentPath -notlike "*$newPath*") {
+    if ($currentPath) {
+        $updatedPath = $newPath + ';' + $currentPath
+    } else {
+        $updatedPath = $newPath
+    }
+    [Environment]::SetEnvironmentVariable('PATH', $updatedPath, 'User')
+    Write-Host "Successfully added $newPath to user PATH"
+} else {
+    Write-Host "PATH already contains $newPath"
+}`, binDir)
+
+	// Execute the PowerShell script
+	execCmd := exec.Command("powershell", "-Command", psScript)
+	output, err := execCmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to update Windows PATH: %v, output: %s", err, string(output))
+	}
+
+	notifyf(obs, EventInfo, "PATH update output: %s", string(output))
+	notify(obs, Event{
+		Kind:    EventPathUpdated,
+		Message: fmt.Sprintf("Successfully updated Windows user PATH to include %s\nNote: You may need to restart your terminal for the PATH change to take effect", binDir),
+	})
+	return nil
+}
+
+/*
+RemovePath removes the managed PATH block from Profile, if set, and the
+configuration files of every supported shell, in case the user's shell
+has changed since the installation, and deletes the env scripts.
+Removing is idempotent: files without a block are left untouched. Lines
+added by hand are never removed.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - []string: The files that were changed or removed
+  - error: Any error editing or removing a file
+*/
+func (u *ShellPathUpdater) RemovePath(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("removing the PATH entry is not supported on Windows")
+	}
+
+	var files []string
+	if u.Profile != "" {
+		files = append(files, u.Profile)
+	}
+	for _, shell := range allShells {
+		files = append(files, shell.knownFiles(u.HomeDir)...)
+	}
+
+	var changed []string
+	for _, configPath := range files {
+		diff, backupPath, err := editBlock(configPath, "")
+		if err != nil {
+			return changed, err
+		}
+		if diff == "" {
+			continue
+		}
+		changed = append(changed, configPath)
+		notify(u.Observer, Event{
+			Kind:    EventPathUpdated,
+			Message: editMessage(fmt.Sprintf("Removed the PATH block from %s", filepath.Base(configPath)), backupPath),
+			Path:    configPath,
+			Diff:    diff,
+		})
+	}
+
+	removed, err := removeEnvScripts(u.HomeDir)
+	for _, scriptPath := range removed {
+		changed = append(changed, scriptPath)
+		notify(u.Observer, Event{
+			Kind:    EventPathUpdated,
+			Message: fmt.Sprintf("Removed %s", scriptPath),
+			Path:    scriptPath,
+		})
+	}
+	return changed, err
+}
+
+/*
+updateUnixPath adds a directory to PATH in shell configuration files.
+It writes the env script for the user's shell, then checks the startup
+files in order of preference and writes the line that loads the script
+inside a managed block, unless PATH already includes the directory (see
+planUnixPath). A block from an earlier installation is updated in place,
+so repeated installations never add duplicates.
+
+Parameters:
+  - binDir: The directory to add to PATH
+  - loginPath: The PATH of a fresh login shell (nil if unknown)
+
+Returns:
+  - bool: Whether a file was changed
+  - error: Any error that occurred during the PATH update process
+*/
+func (u *ShellPathUpdater) updateUnixPath(binDir string, loginPath []string) (bool, error) {
+	change := u.planUnixPath(binDir, loginPath)
+	if change.Present && change.File == "" {
+		notifyf(u.Observer, EventInfo, "PATH already contains %s in a login shell", binDir)
+	}
+	if change.Manual {
+		notify(u.Observer, Event{
+			Kind:    EventInfo,
+			Message: fmt.Sprintf("Shell configuration was not modified. To put %s on PATH, add these lines to %s:\n\n%s", binDir, change.File, change.Line),
+			Path:    change.File,
+		})
+		return false, nil
+	}
+	if change.Script == "" {
+		return false, nil
+	}
+
+	/* The block may be current while the directory or settings in the script changed */
+	if _, err := writeEnvScript(u.Observer, u.shell(), u.HomeDir, binDir, u.Env); err != nil {
+		return false, err
+	}
+	if change.Present {
+		return false, nil
+	}
+
+	candidates := pathCandidates(u.startupFiles(u.shell()))
+	if change.Update {
+		candidates = []string{change.File}
+	}
+
+	/* Try to update each configuration file in order of preference */
+	var errs []error
+	for _, configPath := range candidates {
+		diff, backupPath, err := editBlock(configPath, change.Line)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		notify(u.Observer, Event{
+			Kind:    EventPathUpdated,
+			Message: editMessage(fmt.Sprintf("Updated %s to load %s", filepath.Base(configPath), change.Script), backupPath),
+			Path:    configPath,
+			Diff:    diff,
+		})
+		if u.shell() == ShellNu {
+			notifyf(u.Observer, EventInfo, "nushell stops loading %s if %s is missing; run `ollama-installer path remove` rather than deleting it", filepath.Base(configPath), change.Script)
+		}
+		return true, nil
+	}
+
+	return false, fmt.Errorf("failed to update any shell configuration file: %w", errors.Join(errs...))
+}
+
+/*
+editMessage adds the location of the backup to a message about an edited
+shell configuration file.
+
+Parameters:
+  - message: The message
+  - backupPath: Path of the backup (empty if the file was created)
+
+Returns:
+  - string: The message
+*/
+func editMessage(message, backupPath string) string {
+	if backupPath == "" {
+		return message
+	}
+	return fmt.Sprintf("%s (previous version saved as %s)", message, backupPath)
+}
+
+/*
+planUnixPath works out the line updateUnixPath would write to load the
+env script and the file it would be written to. A file with a managed
+block is reported with Present set if the block already has the line and
+Update set otherwise. Failing that, unless there are OLLAMA_* settings to
+export, Present is set without a File if a fresh login shell already has
+the directory on PATH (e.g. from /etc/profile.d), or with the File that
+already adds the directory to PATH by other means. Script is empty when
+the env script would not be loaded. With NoModify, a change that is not
+Present is reported as Manual with the lines to add by hand.
+
+Parameters:
+  - binDir: The directory to add to PATH
+  - loginPath: The PATH of a fresh login shell (nil if unknown)
+
+Returns:
+  - PathChange: The file and PATH line
+*/
+func (u *ShellPathUpdater) planUnixPath(binDir string, loginPath []string) PathChange {
+	shell := u.shell()
+	files := u.startupFiles(shell)
+	script := shell.EnvScriptPath(u.HomeDir)
+	change := PathChange{Line: shell.SourceLine(u.HomeDir, script), Script: script}
+
+	/* A managed block from an earlier installation is updated in place */
+	for _, file := range files {
+		content, _, _ := readConfigFile(file.path)
+		if lines, ok := blockContent(content); ok {
+			change.File = file.path
+			change.Present = len(lines) == 1 && lines[0] == change.Line
+			change.Update = !change.Present
+			if u.NoModify {
+				return u.manualChange(shell, file.path, binDir, change.Present)
+			}
+			return change
+		}
+	}
+
+	/* The system or the user's own configuration may already provide it */
+	if len(u.Env) == 0 && pathIndex(loginPath, binDir) >= 0 {
+		change.Present = true
+		change.Script = ""
+		return change
+	}
+
+	/* Check if PATH is already set up in any file, e.g. by hand */
+	for _, file := range files {
+		if len(u.Env) == 0 && pathAlreadyExists(file.path, u.HomeDir, binDir) {
+			change.File = file.path
+			change.Present = true
+			change.Script = ""
+			return change
+		}
+	}
+
+	change.File = pathCandidates(files)[0]
+	if u.NoModify {
+		return u.manualChange(shell, change.File, binDir, false)
+	}
+	return change
+}
+
+/*
+manualChange describes a change left for the user to make by hand, since
+NoModify is set. Neither the file nor the env script is written.
+
+Parameters:
+  - shell: The user's shell family
+  - file: The file the lines belong in
+  - binDir: The directory to add to PATH
+  - present: Whether the file already has what it needs
+
+Returns:
+  - PathChange: The file and the lines to add
+*/
+func (u *ShellPathUpdater) manualChange(shell Shell, file, binDir string, present bool) PathChange {
+	if present {
+		return PathChange{File: file, Present: true}
+	}
+	return PathChange{File: file, Line: shell.ManualLines(u.HomeDir, binDir, u.Env), Manual: true}
+}
+
+/*
+pathCandidates lists the shell configuration files that may be written to,
+in order of preference: the startup files that exist or may be created.
+
+Parameters:
+  - files: The shell's startup files (see Shell.startupFiles)
+
+Returns:
+  - []string: Paths of the candidate files
+*/
+func pathCandidates(files []startupFile) []string {
+	var candidates []string
+	for _, file := range files {
+		/* Never create files that would change which files the shell reads */
+		if file.create || fileExists(file.path) {
+			candidates = append(candidates, file.path)
+		}
+	}
+	return candidates
+}
+
+/*
+pathAlreadyExists checks if a shell configuration file already adds the
+directory to PATH outside the managed block, in any of the usual
+spellings (e.g. `export PATH=~/bin:$PATH` or `PATH="${HOME}/bin:$PATH"`).
+
+Parameters:
+  - filePath: Path to the shell configuration file to check
+  - homeDir: The user's home directory path
+  - binDir: The directory to add to PATH
+
+Returns:
+  - bool: true if the file already adds the directory to PATH, false otherwise
+*/
+func pathAlreadyExists(filePath, homeDir, binDir string) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	spellings := dirSpellings(homeDir, binDir)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if addsToPath(scanner.Text(), spellings) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+dirSpellings lists the ways a directory may be written in shell
+configuration: as an absolute path, and relative to $HOME, ${HOME}, ~ or
+nushell's $env.HOME when inside the home directory.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - dir: The directory
+
+Returns:
+  - []string: The spellings
+*/
+func dirSpellings(homeDir, dir string) []string {
+	spellings := []string{dir}
+
+	rel, inHome := homeRelative(homeDir, dir)
+	if !inHome {
+		return spellings
+	}
+	if rel == "." {
+		return append(spellings, "$HOME", "${HOME}", "~", "$env.HOME")
+	}
+
+	rel = filepath.ToSlash(rel)
+	for _, home := range []string{"$HOME", "${HOME}", "~"} {
+		spellings = append(spellings, home+"/"+rel)
+	}
+	return append(spellings, "path join '"+rel+"'", `path join "`+rel+`"`)
+}
+
+/*
+addsToPath reports whether a configuration line, other than a comment,
+sets PATH and mentions the directory in one of its spellings.
+
+Parameters:
+  - line: The configuration line
+  - spellings: The directory's spellings (see dirSpellings)
+
+Returns:
+  - bool: Whether the line adds the directory to PATH
+*/
+func addsToPath(line string, spellings []string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false
+	}
+	if !strings.Contains(line, "PATH") && !strings.Contains(line, "fish_add_path") && !strings.Contains(line, "path add") {
+		return false
+	}
+
+	for _, spelling := range spellings {
+		for rest := line; ; {
+			n := strings.Index(rest, spelling)
+			if n < 0 {
+				break
+			}
+			rest = rest[n+len(spelling):]
+			if endsPathComponent(rest) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+endsPathComponent reports whether the text following a directory ends
+it, so that ~/bin is not mistaken for ~/bin2 or ~/bin/sub.
+
+Parameters:
+  - rest: The text after the directory
+
+Returns:
+  - bool: Whether the directory name ends there
+*/
+func endsPathComponent(rest string) bool {
+	rest = strings.TrimPrefix(rest, "/")
+	if rest == "" {
+		return true
+	}
+	c := rest[0]
+	return !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-')
+}
+
+/*
+fileExists checks if a file exists at the given path.
+It uses os.Stat to check for file existence and properly handles
+the case where the file doesn't exist (os.IsNotExist).
+
+Parameters:
+  - filename: Path to the file to check
+
+Returns:
+  - bool: true if the file exists, false otherwise
+*/
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return !os.IsNotExist(err)
+}
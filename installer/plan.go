@@ -0,0 +1,116 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+/*
+Plan describes what Install would do, as worked out by Installer.Plan
+without downloading the release or changing any files.
+*/
+type Plan struct {
+	/* Version is the release tag that would be installed */
+	Version string
+
+	/* URL is the asset location on the mirror that would be tried first */
+	URL string
+
+	/* Size is the asset size in bytes reported by the mirror (-1 if unknown) */
+	Size int64
+
+	/* Checksum is the published SHA-256 the download would be verified against */
+	Checksum string
+
+	/* InstallPath is where the binary would be installed */
+	InstallPath string
+
+	/* Overwrite is set when a binary already exists at InstallPath */
+	Overwrite bool
+
+	/*
+		PathChange is the PATH update that would be made, or nil when PATH is
+		not updated on this platform or the PathUpdater cannot describe it
+	*/
+	PathChange *PathChange
+}
+
+/*
+Plan resolves the version and asset and reports what Install would do:
+the download size (from a HEAD request), the install path, whether an
+existing binary would be replaced and the PATH change that would be made.
+Nothing is downloaded and no files are modified. A failure is reported
+to the observer as EventFailed, as for Install.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - Plan: The installation plan
+  - error: Any error resolving the release or querying the mirrors
+*/
+func (i *Installer) Plan(ctx context.Context) (Plan, error) {
+	plan, err := i.plan(ctx)
+	if err != nil {
+		notify(i.opts.Observer, Event{Kind: EventFailed, Message: fmt.Sprintf("Dry run failed: %v", err), Version: plan.Version, Err: err})
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+/*
+plan does the work of Plan.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - Plan: The installation plan (with Version set once resolved, even on error)
+  - error: Any error resolving the release or querying the mirrors
+*/
+func (i *Installer) plan(ctx context.Context) (Plan, error) {
+	config := getPlatformConfig()
+
+	version, err := i.resolveVersion(ctx)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	checksum, err := i.checksum(ctx, version, config)
+	if err != nil {
+		return Plan{Version: version}, err
+	}
+
+	urls := make([]string, len(i.opts.Mirrors))
+	for n, mirror := range i.opts.Mirrors {
+		urls[n] = getDownloadURL(mirror, version)
+	}
+	url := rankMirrors(ctx, i.client, urls, i.opts.Observer)[0]
+
+	size, _, err := probeRangeSupport(ctx, i.client, url)
+	if err != nil {
+		return Plan{Version: version}, err
+	}
+
+	binDir, finalPath := i.installPaths(config, version)
+	plan := Plan{
+		Version:     version,
+		URL:         url,
+		Size:        size,
+		Checksum:    checksum,
+		InstallPath: finalPath,
+		Overwrite:   fileExists(finalPath),
+	}
+
+	/* PATH is only updated outside Windows or in CI, matching install */
+	if planner, ok := i.opts.PathUpdater.(PathPlanner); ok && (runtime.GOOS != "windows" || i.opts.CI != nil) {
+		change, err := planner.PlanPath(ctx, i.pathDir(binDir))
+		if err != nil {
+			return Plan{Version: version}, fmt.Errorf("failed to plan PATH update: %w", err)
+		}
+		plan.PathChange = &change
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,101 @@
+package installer
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+/* Minimum time between progress events for one download */
+const progressRedrawInterval = 200 * time.Millisecond
+
+/*
+Progress tracks the number of bytes downloaded and reports it to an
+observer as EventDownloadProgress events. Reports are throttled so that
+frequent small reads do not flood the observer. It is safe for concurrent
+use, so parallel chunks can share one tracker.
+*/
+type Progress struct {
+	Total     int64
+	BytesRead int64
+
+	observer Observer
+	start    time.Time
+	lastSent time.Time
+	mu       sync.Mutex
+}
+
+/*
+NewProgress creates a progress tracker for a download.
+
+Parameters:
+  - total: Expected size in bytes, or a value <= 0 if unknown
+  - obs: Observer to report progress to (may be nil)
+
+Returns:
+  - *Progress: The progress tracker, with its clock started
+*/
+func NewProgress(total int64, obs Observer) *Progress {
+	now := time.Now()
+	return &Progress{
+		Total:    total,
+		observer: obs,
+		start:    now,
+		lastSent: now,
+	}
+}
+
+/*
+Add records n more downloaded bytes and reports them if an update is due.
+*/
+func (p *Progress) Add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.BytesRead += int64(n)
+	if now := time.Now(); now.Sub(p.lastSent) >= progressRedrawInterval {
+		p.lastSent = now
+		p.send(EventDownloadProgress, now)
+	}
+}
+
+/*
+Finish reports the final byte count with an EventDownloadFinished event.
+It should be called once the download completes or fails.
+*/
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.send(EventDownloadFinished, time.Now())
+}
+
+/*
+send delivers a progress event; the caller must hold p.mu.
+*/
+func (p *Progress) send(kind EventKind, now time.Time) {
+	notify(p.observer, Event{
+		Kind:       kind,
+		Time:       now,
+		BytesDone:  p.BytesRead,
+		BytesTotal: p.Total,
+		Elapsed:    now.Sub(p.start),
+	})
+}
+
+/*
+ProgressReader wraps an io.Reader to provide download progress feedback.
+*/
+type ProgressReader struct {
+	Reader   io.Reader
+	Progress *Progress
+}
+
+/*
+Read implements io.Reader interface and tracks progress.
+*/
+func (pr *ProgressReader) Read(p []byte) (int, error) {
+	n, err := pr.Reader.Read(p)
+	pr.Progress.Add(n)
+	return n, err
+}
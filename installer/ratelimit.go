@@ -0,0 +1,153 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+RateLimiter is a token bucket that caps throughput in bytes per second.
+A single limiter can be shared by many readers, in which case the limit
+applies to their combined throughput.
+*/
+type RateLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+/*
+NewRateLimiter creates a token bucket that refills at bytesPerSecond.
+The bucket holds a quarter of a second's worth of tokens so that reads
+stay small enough to keep the rate smooth.
+
+Parameters:
+  - bytesPerSecond: Maximum sustained throughput
+
+Returns:
+  - *RateLimiter: A limiter starting with a full bucket
+*/
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	burst := max(float64(bytesPerSecond)/4, 1)
+	return &RateLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+/*
+take consumes n tokens, waiting until the bucket has refilled enough to
+cover them. Tokens may go negative, in which case later callers wait for
+the debt to be repaid, which keeps concurrent readers fair.
+
+Parameters:
+  - ctx: Context that ends the wait early
+  - n: Number of tokens (bytes) to consume
+
+Returns:
+  - error: The context's cause if it ended before the wait was over
+*/
+func (l *RateLimiter) take(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.burst)
+	l.last = now
+	l.tokens -= float64(n)
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+/*
+RateLimitedReader wraps an io.Reader so that reads honor a RateLimiter.
+A throttled read returns early with the context's error once Context is
+done.
+*/
+type RateLimitedReader struct {
+	Reader  io.Reader
+	Limiter *RateLimiter
+
+	/* Context ends waits for the limiter (context.Background if nil) */
+	Context context.Context
+}
+
+/*
+Read implements io.Reader interface and throttles to the limiter's rate.
+*/
+func (r *RateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > int(r.Limiter.burst) {
+		p = p[:int(r.Limiter.burst)]
+	}
+
+	n, err := r.Reader.Read(p)
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if waitErr := r.Limiter.take(ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+/*
+ParseByteSize parses a size such as "500K", "5M" or "1G" into bytes.
+Suffixes are binary multiples and case-insensitive; a trailing "B" is
+allowed (e.g., "5MB").
+
+Parameters:
+  - value: The size string
+
+Returns:
+  - int64: The size in bytes
+  - error: An error if the value is not a positive, finite size that fits
+    in an int64
+*/
+func ParseByteSize(value string) (int64, error) {
+	s := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	/* ParseFloat accepts "NaN" and "Inf", which are not sizes */
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	size := n * float64(multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", value)
+	}
+	return int64(size), nil
+}
@@ -0,0 +1,79 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "500", want: 500},
+		{value: "500K", want: 500 << 10},
+		{value: "5M", want: 5 << 20},
+		{value: "5mb", want: 5 << 20},
+		{value: "1G", want: 1 << 30},
+		{value: "1.5K", want: 1536},
+		{value: " 2M ", want: 2 << 20},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-5M", wantErr: true},
+		{value: "fast", wantErr: true},
+		{value: "NaN", wantErr: true},
+		{value: "nanM", wantErr: true},
+		{value: "Inf", wantErr: true},
+		{value: "+InfK", wantErr: true},
+		{value: "-Inf", wantErr: true},
+		{value: "1e30G", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseByteSize(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    int64
+		size    int
+		cancel  time.Duration
+		wantErr error
+		maxTime time.Duration
+	}{
+		{name: "within the burst", rate: 1 << 20, size: 1 << 10, maxTime: time.Second},
+		{name: "cancelled while throttled", rate: 4, size: 64, cancel: 50 * time.Millisecond, wantErr: context.Canceled, maxTime: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			reader := &RateLimitedReader{Reader: bytes.NewReader(make([]byte, tt.size)), Limiter: NewRateLimiter(tt.rate), Context: ctx}
+			start := time.Now()
+			_, err := io.Copy(io.Discard, reader)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("io.Copy() error = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.maxTime {
+				t.Errorf("reading took %s, want at most %s", elapsed, tt.maxTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	/* GitHub API endpoint for the latest Ollama release */
+	githubAPIURL = "https://api.github.com/repos/ollama/ollama/releases/latest"
+
+	/* GitHub API endpoint listing all Ollama releases, newest first */
+	githubReleasesURL = "https://api.github.com/repos/ollama/ollama/releases"
+
+	/* Releases requested per page when listing, the API maximum */
+	githubReleasesPerPage = 100
+)
+
+/* Matches a release tag such as v0.5.7 or v0.6.0-rc1 */
+var releaseTagPattern = regexp.MustCompile(`^v\d+\.\d+\.\d+(-[0-9A-Za-z.]+)?$`)
+
+/*
+GitHubRelease represents the structure of a GitHub release API response.
+It contains the tag name which corresponds to the version number.
+*/
+type GitHubRelease struct {
+	/* TagName is the git tag associated with the release (e.g., "v0.1.20") */
+	TagName string `json:"tag_name"`
+
+	/* Prerelease marks release candidates and other unstable releases */
+	Prerelease bool `json:"prerelease"`
+}
+
+/*
+ReleaseLister is implemented by ReleaseSources that can enumerate every
+published release, as needed by `asdf list-all`.
+*/
+type ReleaseLister interface {
+	/* ListReleases returns all releases, oldest first */
+	ListReleases(ctx context.Context) ([]GitHubRelease, error)
+}
+
+/*
+ReleaseSource resolves Ollama releases: which version is current and what
+checksum its assets are expected to have.
+*/
+type ReleaseSource interface {
+	/* LatestVersion returns the tag of the newest release (e.g., "v0.1.20") */
+	LatestVersion(ctx context.Context) (string, error)
+
+	/* Checksum returns the lowercase hex SHA-256 of a release asset */
+	Checksum(ctx context.Context, version, asset string) (string, error)
+}
+
+/*
+GitHubSource is the default ReleaseSource. It reads the latest release from
+the GitHub API and checksums from the release's sha256sum.txt on GitHub,
+falling back to the mirrors only if MirrorChecksums is set.
+*/
+type GitHubSource struct {
+	/* Client performs the requests; it must support file:// for local mirrors */
+	Client *http.Client
+
+	/* Mirrors lists base URLs to look for sha256sum.txt on, in order, after GitHub */
+	Mirrors []string
+
+	/*
+		MirrorChecksums accepts sha256sum.txt from Mirrors when GitHub does
+		not provide it. A compromised mirror could then serve a tampered
+		asset with a matching checksum, so it is off by default
+	*/
+	MirrorChecksums bool
+
+	/* Token is an optional GitHub token sent to the API to raise rate limits */
+	Token string
+}
+
+/*
+LatestVersion fetches the latest Ollama version from the GitHub API.
+It makes an HTTP GET request to the GitHub releases API and parses the response
+to extract the tag name of the latest release.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - string: The version tag (e.g., "v0.1.20")
+  - error: Any error that occurred during the API call or response parsing
+*/
+func (s *GitHubSource) LatestVersion(ctx context.Context) (string, error) {
+	var release GitHubRelease
+	if err := s.getJSON(ctx, githubAPIURL, &release); err != nil {
+		return "", fmt.Errorf("failed to fetch latest release: %w", err)
+	}
+	return release.TagName, nil
+}
+
+/*
+ListReleases implements ReleaseLister by paging through the GitHub API.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+
+Returns:
+  - []GitHubRelease: All published releases, oldest first
+  - error: Any error that occurred during the API calls or response parsing
+*/
+func (s *GitHubSource) ListReleases(ctx context.Context) ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s?per_page=%d&page=%d", githubReleasesURL, githubReleasesPerPage, page)
+		var batch []GitHubRelease
+		if err := s.getJSON(ctx, url, &batch); err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		releases = append(releases, batch...)
+		if len(batch) < githubReleasesPerPage {
+			break
+		}
+	}
+
+	slices.Reverse(releases)
+	return releases, nil
+}
+
+/*
+getJSON makes an authenticated GitHub API request and decodes the response.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - url: The API URL
+  - v: Value to decode the response into
+
+Returns:
+  - error: Any error that occurred during the request or decoding
+*/
+func (s *GitHubSource) getJSON(ctx context.Context, url string, v any) error {
+	/* The client has no overall timeout, so limit this small request here */
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return networkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(url, resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+/*
+NormalizeVersion returns a version in the form used by release tags,
+adding the leading "v" if it is missing (e.g., "0.5.7" becomes "v0.5.7").
+
+Parameters:
+  - version: The version as written by the user
+
+Returns:
+  - string: The release tag
+*/
+func NormalizeVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" || strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
+
+/*
+ValidateVersion checks that a version is a release tag. Versions read from
+files, such as a repository's .ollama-version, become part of file paths,
+so anything else (e.g., "../../tmp/x") is rejected.
+
+Parameters:
+  - version: The release tag, as returned by NormalizeVersion
+
+Returns:
+  - error: An error if the version is not a release tag
+*/
+func ValidateVersion(version string) error {
+	if !releaseTagPattern.MatchString(version) || strings.Contains(version, "..") {
+		return fmt.Errorf("%q is not a release tag (e.g., v0.5.7)", version)
+	}
+	return nil
+}
+
+/*
+Checksum looks up the published SHA-256 of a release asset.
+
+Parameters:
+  - ctx: Context for request cancellation and timeout
+  - version: The version tag (e.g., "v0.1.20")
+  - asset: The asset file name (e.g., "ollama-linux-amd64.tgz")
+
+Returns:
+  - string: The lowercase hex SHA-256 of the asset
+  - error: An error if no mirror provides a checksum for the asset
+*/
+func (s *GitHubSource) Checksum(ctx context.Context, version, asset string) (string, error) {
+	var mirrors []string
+	if s.MirrorChecksums {
+		mirrors = s.Mirrors
+	}
+	return fetchExpectedChecksum(ctx, s.Client, mirrors, version, asset)
+}
@@ -0,0 +1,313 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+/* Name of the systemd user unit that runs `ollama serve` */
+const ServiceUnitName = "ollama.service"
+
+/*
+ServiceConfig describes the `ollama serve` service to generate.
+*/
+type ServiceConfig struct {
+	/* BinaryPath is the ollama binary the service runs */
+	BinaryPath string
+
+	/* Env holds the server's OLLAMA_* settings (e.g. OLLAMA_HOST, OLLAMA_MODELS), by name */
+	Env map[string]string
+}
+
+/*
+ServiceUnit generates the systemd user unit for a service. It depends on
+nothing but cfg, so the output can be checked without systemd.
+
+Parameters:
+  - cfg: The service to generate
+
+Returns:
+  - string: The unit file's contents
+*/
+func ServiceUnit(cfg ServiceConfig) string {
+	var unit strings.Builder
+	unit.WriteString("# Generated by ollama-installer; changes are overwritten by `ollama-installer service install`.\n")
+	unit.WriteString("[Unit]\n")
+	unit.WriteString("Description=Ollama server\n")
+	unit.WriteString("Documentation=https://github.com/ollama/ollama\n")
+	unit.WriteString("\n[Service]\n")
+	fmt.Fprintf(&unit, "ExecStart=%s serve\n", systemdQuote(strings.ReplaceAll(cfg.BinaryPath, "$", "$$")))
+	for _, name := range slices.Sorted(maps.Keys(cfg.Env)) {
+		fmt.Fprintf(&unit, "Environment=%s\n", systemdQuote(name+"="+cfg.Env[name]))
+	}
+	unit.WriteString("Restart=on-failure\n")
+	unit.WriteString("RestartSec=3\n")
+	unit.WriteString("\n[Install]\n")
+	unit.WriteString("WantedBy=default.target\n")
+	return unit.String()
+}
+
+/*
+systemdQuote quotes a value for a unit file setting with double quotes,
+escaping backslashes, quotes, newlines and % (which starts a specifier).
+
+Parameters:
+  - value: The value to quote
+
+Returns:
+  - string: The quoted value
+*/
+func systemdQuote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "%", "%%").Replace(value)
+	return `"` + value + `"`
+}
+
+/*
+ServiceUnitPath returns where the user unit is installed:
+~/.config/systemd/user/ollama.service (under $XDG_CONFIG_HOME if set).
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: Path of the unit file
+*/
+func ServiceUnitPath(homeDir string) string {
+	return filepath.Join(userConfigDir(homeDir), "systemd", "user", ServiceUnitName)
+}
+
+/*
+ServiceManager installs and controls the `ollama serve` systemd user
+service through `systemctl --user`.
+*/
+type ServiceManager struct {
+	/* HomeDir is the home directory the unit is installed under */
+	HomeDir string
+
+	/* Observer receives events describing each step (may be nil) */
+	Observer Observer
+}
+
+/*
+Install writes the unit file, reloads systemd and enables and starts the
+service. As user services stop when the user's last session ends, it also
+reports how to enable lingering if it is off.
+
+Parameters:
+  - ctx: Context for cancellation
+  - cfg: The service to install
+
+Returns:
+  - string: Path of the unit file
+  - error: Any error writing the unit or running systemctl
+*/
+func (m *ServiceManager) Install(ctx context.Context, cfg ServiceConfig) (string, error) {
+	if err := checkSystemd(); err != nil {
+		return "", err
+	}
+
+	unitPath := ServiceUnitPath(m.HomeDir)
+	if err := os.MkdirAll(filepath.Dir(unitPath), executableMode); err != nil {
+		return "", fileError(fmt.Errorf("failed to create %s: %w", filepath.Dir(unitPath), err), nil)
+	}
+	partialPath := unitPath + partialSuffix
+	if err := os.WriteFile(partialPath, []byte(ServiceUnit(cfg)), configFileMode); err != nil {
+		return "", fileError(fmt.Errorf("failed to write %s: %w", unitPath, err), nil)
+	}
+	if err := os.Rename(partialPath, unitPath); err != nil {
+		os.Remove(partialPath)
+		return "", fileError(fmt.Errorf("failed to write %s: %w", unitPath, err), nil)
+	}
+	notify(m.Observer, Event{Kind: EventInstall, Message: fmt.Sprintf("Wrote %s", unitPath), Path: unitPath})
+
+	if err := systemctl(ctx, "daemon-reload"); err != nil {
+		return unitPath, err
+	}
+	/* Restart rather than start, so a reinstall picks up the new unit */
+	if err := systemctl(ctx, "enable", ServiceUnitName); err != nil {
+		return unitPath, err
+	}
+	if err := systemctl(ctx, "restart", ServiceUnitName); err != nil {
+		return unitPath, err
+	}
+	notifyf(m.Observer, EventInfo, "Enabled and started %s; it starts when you log in", ServiceUnitName)
+
+	if name, lingering, err := userLingering(ctx); err == nil && !lingering {
+		notifyf(m.Observer, EventInfo, "The service stops when you log out. To keep it running and start it at boot, run: sudo loginctl enable-linger %s", name)
+	}
+	return unitPath, nil
+}
+
+/*
+Start starts the service.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - error: Any error running systemctl
+*/
+func (m *ServiceManager) Start(ctx context.Context) error {
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+	if err := systemctl(ctx, "start", ServiceUnitName); err != nil {
+		return err
+	}
+	notifyf(m.Observer, EventInfo, "Started %s", ServiceUnitName)
+	return nil
+}
+
+/*
+Stop stops the service until it is started again or the user next logs in.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - error: Any error running systemctl
+*/
+func (m *ServiceManager) Stop(ctx context.Context) error {
+	if err := checkSystemd(); err != nil {
+		return err
+	}
+	if err := systemctl(ctx, "stop", ServiceUnitName); err != nil {
+		return err
+	}
+	notifyf(m.Observer, EventInfo, "Stopped %s", ServiceUnitName)
+	return nil
+}
+
+/*
+Status writes `systemctl --user status` for the service to w.
+
+Parameters:
+  - ctx: Context for cancellation
+  - w: Where to write the status
+
+Returns:
+  - bool: Whether the service is running
+  - error: Any error other than the service not running
+*/
+func (m *ServiceManager) Status(ctx context.Context, w io.Writer) (bool, error) {
+	if err := checkSystemd(); err != nil {
+		return false, err
+	}
+	cmd := exec.CommandContext(ctx, "systemctl", "--user", "--no-pager", "status", ServiceUnitName)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+
+	/* status exits with 3 when the unit is not running */
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to run systemctl --user status: %w", err)
+	}
+	return true, nil
+}
+
+/*
+Uninstall stops and disables the service and removes the unit file.
+Running it again changes nothing.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - bool: Whether the unit was installed
+  - error: Any error running systemctl or removing the unit
+*/
+func (m *ServiceManager) Uninstall(ctx context.Context) (bool, error) {
+	if err := checkSystemd(); err != nil {
+		return false, err
+	}
+	unitPath := ServiceUnitPath(m.HomeDir)
+	if !fileExists(unitPath) {
+		return false, nil
+	}
+
+	if err := systemctl(ctx, "disable", "--now", ServiceUnitName); err != nil {
+		return true, err
+	}
+	if err := os.Remove(unitPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return true, fileError(fmt.Errorf("failed to remove %s: %w", unitPath, err), nil)
+	}
+	if err := systemctl(ctx, "daemon-reload"); err != nil {
+		return true, err
+	}
+	notify(m.Observer, Event{Kind: EventInfo, Message: fmt.Sprintf("Stopped %s and removed %s", ServiceUnitName, unitPath), Path: unitPath})
+	return true, nil
+}
+
+/*
+checkSystemd reports whether systemd user services can be managed here.
+
+Returns:
+  - error: An error if systemctl is missing or this is not Linux
+*/
+func checkSystemd() error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("the service requires systemd, which is not available on %s; run `ollama serve` instead", runtime.GOOS)
+	}
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return fmt.Errorf("the service requires systemd, but systemctl was not found; run `ollama serve` instead")
+	}
+	return nil
+}
+
+/*
+systemctl runs `systemctl --user` with the given arguments.
+
+Parameters:
+  - ctx: Context for cancellation
+  - args: The systemctl arguments
+
+Returns:
+  - error: Any error running systemctl, including its output
+*/
+func systemctl(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "systemctl", append([]string{"--user"}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run systemctl --user %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+/*
+userLingering asks logind whether the current user's services keep running
+after they log out.
+
+Parameters:
+  - ctx: Context for cancellation
+
+Returns:
+  - string: The user's name
+  - bool: Whether lingering is enabled
+  - error: Any error looking up the user or querying logind
+*/
+func userLingering(ctx context.Context) (string, bool, error) {
+	current, err := user.Current()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up the current user: %w", err)
+	}
+	output, err := exec.CommandContext(ctx, "loginctl", "show-user", current.Username, "--property=Linger").Output()
+	if err != nil {
+		return current.Username, false, fmt.Errorf("failed to run loginctl: %w", err)
+	}
+	return current.Username, strings.TrimSpace(string(output)) == "Linger=yes", nil
+}
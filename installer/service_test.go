@@ -0,0 +1,100 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestServiceUnit(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ServiceConfig
+		want []string
+	}{
+		{
+			name: "plain path",
+			cfg:  ServiceConfig{BinaryPath: "/home/user/bin/ollama"},
+			want: []string{`ExecStart="/home/user/bin/ollama" serve`},
+		},
+		{
+			name: "path with spaces",
+			cfg:  ServiceConfig{BinaryPath: "/home/user/my apps/ollama"},
+			want: []string{`ExecStart="/home/user/my apps/ollama" serve`},
+		},
+		{
+			name: "dollar and percent in the path",
+			cfg:  ServiceConfig{BinaryPath: "/opt/$HOME/100%/ollama"},
+			want: []string{`ExecStart="/opt/$$HOME/100%%/ollama" serve`},
+		},
+		{
+			name: "backslash, quote and newline in the path",
+			cfg:  ServiceConfig{BinaryPath: "/opt/a\\b/\"c\"/d\ne/ollama"},
+			want: []string{`ExecStart="/opt/a\\b/\"c\"/d\ne/ollama" serve`},
+		},
+		{
+			name: "environment sorted by name",
+			cfg: ServiceConfig{
+				BinaryPath: "/usr/bin/ollama",
+				Env: map[string]string{
+					"OLLAMA_MODELS":     "/srv/models",
+					"OLLAMA_HOST":       "0.0.0.0:11434",
+					"OLLAMA_KEEP_ALIVE": "10m",
+				},
+			},
+			want: []string{
+				`ExecStart="/usr/bin/ollama" serve` + "\n" +
+					`Environment="OLLAMA_HOST=0.0.0.0:11434"` + "\n" +
+					`Environment="OLLAMA_KEEP_ALIVE=10m"` + "\n" +
+					`Environment="OLLAMA_MODELS=/srv/models"` + "\n" +
+					"Restart=on-failure\n",
+			},
+		},
+		{
+			name: "environment values escaped",
+			cfg: ServiceConfig{
+				BinaryPath: "/usr/bin/ollama",
+				Env: map[string]string{
+					"OLLAMA_ORIGINS": `http://a, "b"`,
+					"OLLAMA_MODELS":  `C:\models\100%`,
+					"OLLAMA_NOTE":    "two\nlines",
+				},
+			},
+			want: []string{
+				`Environment="OLLAMA_MODELS=C:\\models\\100%%"`,
+				`Environment="OLLAMA_NOTE=two\nlines"`,
+				`Environment="OLLAMA_ORIGINS=http://a, \"b\""`,
+			},
+		},
+		{
+			name: "sections",
+			cfg:  ServiceConfig{BinaryPath: "/usr/bin/ollama"},
+			want: []string{
+				"[Unit]\nDescription=Ollama server\n",
+				"\n[Service]\nExecStart=",
+				"Restart=on-failure\nRestartSec=3\n",
+				"\n[Install]\nWantedBy=default.target\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit := ServiceUnit(tt.cfg)
+			for _, want := range tt.want {
+				if !strings.Contains(unit, want) {
+					t.Errorf("ServiceUnit() does not contain %q:\n%s", want, unit)
+				}
+			}
+			if !strings.HasSuffix(unit, "[Install]\nWantedBy=default.target\n") {
+				t.Errorf("ServiceUnit() does not end with the [Install] section:\n%s", unit)
+			}
+
+			/* An unescaped newline would leave a line that is not a setting */
+			for _, line := range strings.Split(strings.TrimSuffix(unit, "\n"), "\n") {
+				if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+					t.Errorf("ServiceUnit() has an invalid line %q:\n%s", line, unit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,578 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Shell is a family of shells that share configuration files and syntax for
+setting PATH.
+*/
+type Shell string
+
+const (
+	/* ShellPOSIX covers sh, ksh, dash and other POSIX shells */
+	ShellPOSIX Shell = "posix"
+
+	/* ShellBash is bash, which shares POSIX syntax but has its own startup files */
+	ShellBash Shell = "bash"
+
+	/* ShellZsh is zsh, which shares POSIX syntax but has its own startup files */
+	ShellZsh Shell = "zsh"
+
+	/* ShellFish is the fish shell */
+	ShellFish Shell = "fish"
+
+	/* ShellCsh is csh */
+	ShellCsh Shell = "csh"
+
+	/* ShellTcsh is tcsh, which shares csh syntax but has its own startup file */
+	ShellTcsh Shell = "tcsh"
+
+	/* ShellNu is nushell */
+	ShellNu Shell = "nu"
+)
+
+/* Name of the fish conf.d snippet that adds the install directory to PATH */
+const fishConfFileName = "ollama-installer.fish"
+
+/* Timeout for looking up the login shell with getent */
+const getentTimeout = 5 * time.Second
+
+/* Shells whose configuration files RemovePath cleans up */
+var allShells = []Shell{ShellPOSIX, ShellBash, ShellZsh, ShellFish, ShellCsh, ShellTcsh, ShellNu}
+
+/* bash login files; bash reads the first one that exists and ignores the rest */
+var bashLoginFiles = []string{".bash_profile", ".bash_login", ".profile"}
+
+/* Matches a line that sources a file in the home directory, captured without its directory */
+var sourceLine = regexp.MustCompile(`(?:^|[\s;&|{(])(?:\.|source)\s+["']?(?:(?:\$HOME|\$\{HOME\}|~)/)?([^\s"';)/]+)`)
+
+/*
+DetectShell returns the name of the user's shell (e.g., "zsh"), taken from
+$SHELL or, if that is unset, from the user's passwd entry.
+
+Returns:
+  - string: The shell's name, or empty if it cannot be determined
+*/
+func DetectShell() string {
+	if shell := detectShellPath(); shell != "" {
+		return filepath.Base(shell)
+	}
+	return ""
+}
+
+/*
+detectShellPath returns the path of the user's shell from $SHELL or, if
+that is unset, from the user's passwd entry.
+
+Returns:
+  - string: The shell's path, or empty if it cannot be determined
+*/
+func detectShellPath() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return passwdShell()
+}
+
+/*
+passwdShell returns the login shell in the current user's passwd entry,
+asking getent first so that directory services such as LDAP are included
+and falling back to /etc/passwd.
+
+Returns:
+  - string: Path of the login shell, or empty if it cannot be determined
+*/
+func passwdShell() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	uid := strconv.Itoa(os.Getuid())
+
+	ctx, cancel := context.WithTimeout(context.Background(), getentTimeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, "getent", "passwd", uid).Output(); err == nil {
+		if shell, ok := passwdEntryShell(strings.TrimSpace(string(output)), uid); ok {
+			return shell
+		}
+	}
+
+	file, err := os.Open("/etc/passwd")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if shell, ok := passwdEntryShell(scanner.Text(), uid); ok {
+			return shell
+		}
+	}
+	return ""
+}
+
+/*
+rootShell returns the login shell of the user whose home directory is
+homeDir in the /etc/passwd of another system's root file system, defaulting
+to /bin/sh, which most images have.
+
+Parameters:
+  - root: The other system's root directory
+  - homeDir: The user's home directory under root
+
+Returns:
+  - string: Path of the login shell on that system
+*/
+func rootShell(root, homeDir string) string {
+	const defaultShell = "/bin/sh"
+
+	rel, err := filepath.Rel(root, homeDir)
+	if err != nil {
+		return defaultShell
+	}
+	home := filepath.Join("/", rel)
+
+	file, err := os.Open(filepath.Join(root, "etc", "passwd"))
+	if err != nil {
+		return defaultShell
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) >= 7 && fields[5] == home && fields[6] != "" {
+			return fields[6]
+		}
+	}
+	return defaultShell
+}
+
+/*
+RootUserHome looks up a user's home directory in the /etc/passwd of another
+system's root file system, for installing into an image for a user other
+than the current one.
+
+Parameters:
+  - root: The other system's root directory
+  - name: The user name
+
+Returns:
+  - string: The home directory on that system (e.g. /home/app)
+  - error: An error if the file cannot be read or has no such user
+*/
+func RootUserHome(root, name string) (string, error) {
+	path := filepath.Join(root, "etc", "passwd")
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) >= 7 && fields[0] == name && fields[5] != "" {
+			return fields[5], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no user %s", path, name)
+}
+
+/*
+passwdEntryShell returns the shell field of a passwd line if it belongs to uid.
+
+Parameters:
+  - line: A line in passwd format (name:password:uid:gid:gecos:home:shell)
+  - uid: The user ID to match
+
+Returns:
+  - string: The shell field
+  - bool: Whether the line is uid's entry
+*/
+func passwdEntryShell(line, uid string) (string, bool) {
+	fields := strings.Split(line, ":")
+	if len(fields) < 7 || fields[2] != uid {
+		return "", false
+	}
+	return fields[6], true
+}
+
+/*
+ParseShell returns the family of a shell given by name or path. Unknown
+shells are assumed to be POSIX compatible.
+
+Parameters:
+  - name: The shell's name or path (e.g., "tcsh" or "/usr/bin/fish")
+
+Returns:
+  - Shell: The shell family
+*/
+func ParseShell(name string) Shell {
+	switch strings.TrimSuffix(filepath.Base(name), ".exe") {
+	case "bash":
+		return ShellBash
+	case "zsh":
+		return ShellZsh
+	case "fish":
+		return ShellFish
+	case "csh":
+		return ShellCsh
+	case "tcsh":
+		return ShellTcsh
+	case "nu", "nushell":
+		return ShellNu
+	default:
+		return ShellPOSIX
+	}
+}
+
+/*
+PathLine returns the configuration line that prepends binDir to PATH in
+this shell's syntax. Directories inside the home directory are written
+relative to the home directory so the line keeps working if it moves.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - binDir: The directory to add to PATH
+
+Returns:
+  - string: The configuration line (e.g., `export PATH="$HOME/bin:$PATH"`)
+*/
+func (s Shell) PathLine(homeDir, binDir string) string {
+	switch s {
+	case ShellFish:
+		return fmt.Sprintf(`fish_add_path -g "%s"`, homePath(homeDir, binDir))
+	case ShellCsh, ShellTcsh:
+		return fmt.Sprintf(`setenv PATH "%s:$PATH"`, homePath(homeDir, binDir))
+	case ShellNu:
+		return fmt.Sprintf("$env.PATH = ($env.PATH | split row (char esep) | prepend %s)", nuPath(homeDir, binDir))
+	default:
+		return fmt.Sprintf(`export PATH="%s:$PATH"`, homePath(homeDir, binDir))
+	}
+}
+
+/*
+homeRelative returns dir relative to the home directory.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - dir: The directory
+
+Returns:
+  - string: The relative path ("." for the home directory itself)
+  - bool: Whether dir is inside the home directory
+*/
+func homeRelative(homeDir, dir string) (string, bool) {
+	rel, err := filepath.Rel(homeDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+/*
+homePath writes a directory relative to $HOME when it is inside the home
+directory, and as given otherwise.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - dir: The directory
+
+Returns:
+  - string: The directory for use in a configuration line
+*/
+func homePath(homeDir, dir string) string {
+	rel, inHome := homeRelative(homeDir, dir)
+	switch {
+	case !inHome:
+		return dir
+	case rel == ".":
+		return "$HOME"
+	}
+	return "$HOME/" + filepath.ToSlash(rel)
+}
+
+/*
+nuPath writes a directory as a nushell expression, relative to $env.HOME
+when it is inside the home directory.
+
+Parameters:
+  - homeDir: The user's home directory path
+  - dir: The directory
+
+Returns:
+  - string: The expression
+*/
+func nuPath(homeDir, dir string) string {
+	rel, inHome := homeRelative(homeDir, dir)
+	switch {
+	case !inHome:
+		return nuString(dir)
+	case rel == ".":
+		return "$env.HOME"
+	}
+	return fmt.Sprintf("($env.HOME | path join %s)", nuString(filepath.ToSlash(rel)))
+}
+
+/*
+nuString quotes a string for nushell as a raw single-quoted literal, which
+has no escapes; strings containing a quote use a raw string instead.
+
+Parameters:
+  - s: The string to quote
+
+Returns:
+  - string: The nushell literal
+*/
+func nuString(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return "r#'" + s + "'#"
+}
+
+/*
+startupFile is a configuration file in a shell's startup chain.
+*/
+type startupFile struct {
+	/* path is the location of the file */
+	path string
+
+	/*
+		create allows creating the file when it does not exist. Only files
+		that the shell reads whenever they exist, without others then being
+		skipped, may be created.
+	*/
+	create bool
+}
+
+/*
+startupFiles lists the configuration files the shell actually reads at
+startup, given the files that exist, in order of preference for adding
+the PATH line. Files that would hide others are never created: bash, for
+example, stops reading .profile once .bash_profile exists.
+
+  - zsh: .zshrc, .zprofile, .zshenv and .zlogin in $ZDOTDIR (default ~)
+  - bash: the login file in use (the first of .bash_profile, .bash_login and
+    .profile, or a new .profile if none exists) and .bashrc; .bashrc comes
+    first if the login file sources it, and .profile is included if
+    .bash_profile or .bash_login sources it
+  - other POSIX shells: .profile
+  - tcsh: .tcshrc if it exists, otherwise .cshrc
+  - csh: .cshrc
+  - fish and nushell: the file the installer manages
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - []startupFile: The files
+*/
+func (s Shell) startupFiles(homeDir string) []startupFile {
+	home := func(name string, create bool) startupFile {
+		return startupFile{path: filepath.Join(homeDir, name), create: create}
+	}
+
+	switch s {
+	case ShellZsh:
+		dir := zshConfigDir(homeDir)
+		return []startupFile{
+			{path: filepath.Join(dir, ".zshrc"), create: true},
+			{path: filepath.Join(dir, ".zprofile")},
+			{path: filepath.Join(dir, ".zshenv")},
+			{path: filepath.Join(dir, ".zlogin")},
+		}
+	case ShellBash:
+		return bashStartupFiles(homeDir)
+	case ShellFish:
+		return []startupFile{{path: fishConfPath(homeDir), create: true}}
+	case ShellNu:
+		return []startupFile{{path: filepath.Join(nuConfigDir(homeDir), "env.nu"), create: true}}
+	case ShellTcsh:
+		if fileExists(filepath.Join(homeDir, ".tcshrc")) {
+			return []startupFile{home(".tcshrc", false)}
+		}
+		return []startupFile{home(".cshrc", true)}
+	case ShellCsh:
+		return []startupFile{home(".cshrc", true)}
+	default:
+		return []startupFile{home(".profile", true)}
+	}
+}
+
+/*
+bashStartupFiles works out bash's startup chain (see startupFiles).
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - []startupFile: The files, in order of preference
+*/
+func bashStartupFiles(homeDir string) []startupFile {
+	bashrc := startupFile{path: filepath.Join(homeDir, ".bashrc")}
+
+	var login startupFile
+	for _, name := range bashLoginFiles {
+		if path := filepath.Join(homeDir, name); fileExists(path) {
+			login = startupFile{path: path}
+			break
+		}
+	}
+	if login.path == "" {
+		/* With no login file bash reads .profile if it is created */
+		return []startupFile{{path: filepath.Join(homeDir, ".profile"), create: true}, bashrc}
+	}
+
+	sourced := sourcedFiles(login.path)
+	files := []startupFile{login, bashrc}
+	if slices.Contains(sourced, ".bashrc") {
+		files = []startupFile{bashrc, login}
+	}
+	if filepath.Base(login.path) != ".profile" && slices.Contains(sourced, ".profile") {
+		files = append(files, startupFile{path: filepath.Join(homeDir, ".profile")})
+	}
+	return files
+}
+
+/*
+sourcedFiles lists the home directory files a shell script sources with
+`.` or `source` (e.g. `. "$HOME/.bashrc"`), ignoring comments.
+
+Parameters:
+  - path: Path of the script
+
+Returns:
+  - []string: Names of the sourced files
+*/
+func sourcedFiles(path string) []string {
+	content, _, err := readConfigFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, line := range splitLines(content) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, match := range sourceLine.FindAllStringSubmatch(line, -1) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+/*
+knownFiles lists every configuration file of the shell the installer may
+have edited, whether or not it is currently read.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - []string: Paths of the files
+*/
+func (s Shell) knownFiles(homeDir string) []string {
+	var names []string
+	switch s {
+	case ShellZsh:
+		dir := zshConfigDir(homeDir)
+		return []string{filepath.Join(dir, ".zshrc"), filepath.Join(dir, ".zprofile"), filepath.Join(dir, ".zshenv"), filepath.Join(dir, ".zlogin")}
+	case ShellBash:
+		names = append(slices.Clone(bashLoginFiles), ".bashrc")
+	case ShellTcsh:
+		names = []string{".tcshrc", ".cshrc"}
+	case ShellCsh:
+		names = []string{".cshrc"}
+	case ShellFish, ShellNu:
+		files := s.startupFiles(homeDir)
+		return []string{files[0].path}
+	default:
+		names = []string{".profile"}
+	}
+
+	files := make([]string, len(names))
+	for n, name := range names {
+		files[n] = filepath.Join(homeDir, name)
+	}
+	return files
+}
+
+/*
+zshConfigDir returns the directory zsh reads its startup files from.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: $ZDOTDIR if set, otherwise the home directory
+*/
+func zshConfigDir(homeDir string) string {
+	if dir := os.Getenv("ZDOTDIR"); dir != "" {
+		return dir
+	}
+	return homeDir
+}
+
+/*
+fishConfPath returns the fish conf.d snippet the installer manages.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: Path of the snippet
+*/
+func fishConfPath(homeDir string) string {
+	return filepath.Join(userConfigDir(homeDir), "fish", "conf.d", fishConfFileName)
+}
+
+/*
+userConfigDir returns $XDG_CONFIG_HOME, defaulting to ~/.config, where fish
+and nushell (on Linux) look for their configuration.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: The configuration directory
+*/
+func userConfigDir(homeDir string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir, ".config")
+}
+
+/*
+nuConfigDir returns nushell's configuration directory, which on macOS is
+in Application Support unless XDG_CONFIG_HOME is set.
+
+Parameters:
+  - homeDir: The user's home directory path
+
+Returns:
+  - string: The nushell configuration directory
+*/
+func nuConfigDir(homeDir string) string {
+	if runtime.GOOS == "darwin" && os.Getenv("XDG_CONFIG_HOME") == "" {
+		return filepath.Join(homeDir, "Library", "Application Support", "nushell")
+	}
+	return filepath.Join(userConfigDir(homeDir), "nushell")
+}
@@ -0,0 +1,79 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRootUserHome(t *testing.T) {
+	root := t.TempDir()
+	passwd := "root:x:0:0:root:/root:/bin/bash\n" +
+		"app:x:1000:1000:App,,,:/home/app:/usr/bin/zsh\n" +
+		"nohome:x:1001:1001:::/bin/sh\n" +
+		"short:x:1002\n"
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "etc", "passwd"), []byte(passwd), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		root    string
+		user    string
+		want    string
+		wantErr bool
+	}{
+		{name: "user", root: root, user: "app", want: "/home/app"},
+		{name: "root user", root: root, user: "root", want: "/root"},
+		{name: "unknown user", root: root, user: "nobody", wantErr: true},
+		{name: "user without a home", root: root, user: "nohome", wantErr: true},
+		{name: "malformed entry", root: root, user: "short", wantErr: true},
+		{name: "no passwd file", root: t.TempDir(), user: "app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RootUserHome(tt.root, tt.user)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("RootUserHome(%q) = %q, %v, want %q (error %v)", tt.user, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCshStartupFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		shell  string
+		tcshrc bool
+		want   string
+		script string
+	}{
+		{name: "csh", shell: "/bin/csh", want: ".cshrc", script: "env.csh"},
+		{name: "csh ignores .tcshrc", shell: "/bin/csh", tcshrc: true, want: ".cshrc", script: "env.csh"},
+		{name: "tcsh without .tcshrc", shell: "/usr/bin/tcsh", want: ".cshrc", script: "env.csh"},
+		{name: "tcsh with .tcshrc", shell: "/usr/bin/tcsh", tcshrc: true, want: ".tcshrc", script: "env.csh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			if tt.tcshrc {
+				if err := os.WriteFile(filepath.Join(home, ".tcshrc"), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			shell := ParseShell(tt.shell)
+			files := shell.startupFiles(home)
+			if len(files) != 1 || files[0].path != filepath.Join(home, tt.want) {
+				t.Errorf("startupFiles() = %v, want %s", files, tt.want)
+			}
+			if got := filepath.Base(shell.EnvScriptPath(home)); got != tt.script {
+				t.Errorf("EnvScriptPath() = %s, want %s", got, tt.script)
+			}
+		})
+	}
+}
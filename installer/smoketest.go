@@ -0,0 +1,219 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	/* Smoke test timeouts */
+	versionCheckTimeout = 30 * time.Second
+	serveProbeTimeout   = 30 * time.Second
+	serveProbeInterval  = 250 * time.Millisecond
+)
+
+/*
+Patterns matching the version reported by `ollama --version`. When no server
+is running the binary prints "Warning: client version is X"; otherwise it
+prints "ollama version is X".
+*/
+var (
+	clientVersionPattern = regexp.MustCompile(`client version is (\S+)`)
+	versionPattern       = regexp.MustCompile(`version is (\S+)`)
+)
+
+/*
+smokeTest runs the installed binary to confirm that it starts and reports
+the expected version. When serveCheck is set, it also starts `ollama serve`
+on a throwaway local port and checks the version reported by /api/version.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binaryPath: Path to the installed binary
+  - version: The release tag that was installed (e.g., "v0.1.20")
+  - serveCheck: Whether to also probe a temporary `ollama serve` instance
+  - obs: Observer to report successful checks to (may be nil)
+
+Returns:
+  - error: Any error indicating the installed binary is not working
+*/
+func smokeTest(ctx context.Context, binaryPath, version string, serveCheck bool, obs Observer) error {
+	want := strings.TrimPrefix(version, "v")
+
+	reported, err := binaryVersion(ctx, binaryPath)
+	if err != nil {
+		return err
+	}
+	if reported != want {
+		return fmt.Errorf("installed binary reports version %s, expected %s", reported, want)
+	}
+	notify(obs, Event{
+		Kind:    EventVerify,
+		Message: fmt.Sprintf("Verified %s --version reports %s", binaryPath, reported),
+		Path:    binaryPath,
+		Version: version,
+	})
+
+	if !serveCheck {
+		return nil
+	}
+
+	served, err := probeServe(ctx, binaryPath)
+	if err != nil {
+		return fmt.Errorf("ollama serve check failed: %w", err)
+	}
+	if served != want {
+		return fmt.Errorf("ollama serve reports version %s, expected %s", served, want)
+	}
+	notify(obs, Event{
+		Kind:    EventVerify,
+		Message: fmt.Sprintf("Verified ollama serve responds with version %s", served),
+		Path:    binaryPath,
+		Version: version,
+	})
+	return nil
+}
+
+/*
+binaryVersion runs `<binary> --version` with a timeout and extracts the
+version number from its output.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binaryPath: Path to the binary to run
+
+Returns:
+  - string: The reported version without a "v" prefix (e.g., "0.1.20")
+  - error: Any error running the binary or parsing its output
+*/
+func binaryVersion(ctx context.Context, binaryPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binaryPath, "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s --version: %w, output: %s", binaryPath, err, strings.TrimSpace(string(output)))
+	}
+
+	version := parseOllamaVersion(string(output))
+	if version == "" {
+		return "", fmt.Errorf("could not find a version in output: %s", strings.TrimSpace(string(output)))
+	}
+	return version, nil
+}
+
+/*
+parseOllamaVersion extracts the version number from `ollama --version`
+output, preferring the client version when both are printed.
+
+Parameters:
+  - output: Combined output of `ollama --version`
+
+Returns:
+  - string: The version number, or an empty string if none was found
+*/
+func parseOllamaVersion(output string) string {
+	if match := clientVersionPattern.FindStringSubmatch(output); match != nil {
+		return strings.TrimPrefix(match[1], "v")
+	}
+	if match := versionPattern.FindStringSubmatch(output); match != nil {
+		return strings.TrimPrefix(match[1], "v")
+	}
+	return ""
+}
+
+/*
+probeServe starts `ollama serve` bound to a free loopback port, waits for
+/api/version to respond, and stops the server again.
+
+Parameters:
+  - ctx: Context for cancellation
+  - binaryPath: Path to the binary to run
+
+Returns:
+  - string: The version reported by the server
+  - error: Any error starting the server or querying it
+*/
+func probeServe(ctx context.Context, binaryPath string) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to find a free port: %w", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, serveProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, binaryPath, "serve")
+	cmd.Env = append(os.Environ(), "OLLAMA_HOST="+addr)
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start ollama serve: %w", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	client := &http.Client{
+		Timeout: serveProbeInterval * 4,
+	}
+	url := "http://" + addr + "/api/version"
+
+	for {
+		if version, err := fetchServeVersion(ctx, client, url); err == nil {
+			return version, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("no response from %s within %s", url, serveProbeTimeout)
+		case <-time.After(serveProbeInterval):
+		}
+	}
+}
+
+/*
+fetchServeVersion queries a running server's /api/version endpoint once.
+
+Parameters:
+  - ctx: Context for cancellation
+  - client: HTTP client to use
+  - url: Full URL of the /api/version endpoint
+
+Returns:
+  - string: The version reported by the server
+  - error: Any error making the request or decoding the response
+*/
+func fetchServeVersion(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(body.Version, "v"), nil
+}
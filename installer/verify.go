@@ -0,0 +1,297 @@
+package installer
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+binaryInfo describes the executable format and CPU architectures found
+in a binary. Architectures use GOARCH naming (e.g., "amd64", "arm64").
+*/
+type binaryInfo struct {
+	format string
+	archs  []string
+}
+
+/*
+validateBinary checks that the file at path is an executable that can run
+on the given platform. It confirms the executable format matches goos
+(ELF for Linux, Mach-O for macOS, PE for Windows), that one of the machine
+types matches goarch, and that the file is not shorter than its headers
+claim.
+
+Parameters:
+  - path: Path to the extracted binary
+  - goos: Target operating system (e.g., "linux")
+  - goarch: Target architecture (e.g., "amd64")
+
+Returns:
+  - error: A descriptive error if the binary cannot run on the target platform
+*/
+func validateBinary(path, goos, goarch string) error {
+	info, err := inspectBinary(path)
+	if err != nil {
+		return err
+	}
+
+	if want := executableFormat(goos); info.format != want {
+		return fmt.Errorf("archive contains %s %s, host is %s (expects %s)",
+			strings.Join(info.archs, "+"), info.format, goos, want)
+	}
+
+	for _, arch := range info.archs {
+		if arch == goarch {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("archive contains %s %s, host is %s",
+		strings.Join(info.archs, "+"), info.format, goarch)
+}
+
+/*
+executableFormat returns the name of the native executable format for an
+operating system.
+
+Parameters:
+  - goos: Operating system name (e.g., "darwin")
+
+Returns:
+  - string: "PE", "Mach-O" or "ELF"
+*/
+func executableFormat(goos string) string {
+	switch goos {
+	case "windows":
+		return "PE"
+	case "darwin", "ios":
+		return "Mach-O"
+	default:
+		return "ELF"
+	}
+}
+
+/*
+inspectBinary detects the executable format of a file and the CPU
+architectures it was built for. Universal (fat) Mach-O files report
+every architecture they contain.
+
+Parameters:
+  - path: Path to the binary to inspect
+
+Returns:
+  - binaryInfo: Format and architectures of the binary
+  - error: An error if the file is not a recognized or complete executable
+*/
+func inspectBinary(path string) (binaryInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return binaryInfo{}, fmt.Errorf("failed to stat binary: %w", err)
+	}
+	size := stat.Size()
+
+	format, err := detectFormat(path)
+	if err != nil {
+		return binaryInfo{}, err
+	}
+
+	switch format {
+	case "ELF":
+		f, err := elf.Open(path)
+		if err != nil {
+			return binaryInfo{}, fmt.Errorf("truncated or corrupt ELF binary: %w", err)
+		}
+		defer f.Close()
+		var end int64
+		for _, prog := range f.Progs {
+			end = max(end, int64(prog.Off+prog.Filesz))
+		}
+		if err := checkExtent(size, end); err != nil {
+			return binaryInfo{}, err
+		}
+		return binaryInfo{format: format, archs: []string{elfArch(f)}}, nil
+
+	case "Mach-O":
+		if f, err := macho.Open(path); err == nil {
+			defer f.Close()
+			if err := checkExtent(size, machoExtent(f, 0)); err != nil {
+				return binaryInfo{}, err
+			}
+			return binaryInfo{format: format, archs: []string{machoArch(f.Cpu)}}, nil
+		}
+		f, err := macho.OpenFat(path)
+		if err != nil {
+			return binaryInfo{}, fmt.Errorf("truncated or corrupt Mach-O binary: %w", err)
+		}
+		defer f.Close()
+		info := binaryInfo{format: format}
+		for _, arch := range f.Arches {
+			if err := checkExtent(size, machoExtent(arch.File, int64(arch.Offset))); err != nil {
+				return binaryInfo{}, err
+			}
+			info.archs = append(info.archs, machoArch(arch.Cpu))
+		}
+		return info, nil
+
+	case "PE":
+		f, err := pe.Open(path)
+		if err != nil {
+			return binaryInfo{}, fmt.Errorf("truncated or corrupt PE binary: %w", err)
+		}
+		defer f.Close()
+		var end int64
+		for _, section := range f.Sections {
+			end = max(end, int64(section.Offset)+int64(section.Size))
+		}
+		if err := checkExtent(size, end); err != nil {
+			return binaryInfo{}, err
+		}
+		return binaryInfo{format: format, archs: []string{peArch(f.Machine)}}, nil
+	}
+
+	return binaryInfo{}, fmt.Errorf("unsupported executable format %s", format)
+}
+
+/*
+detectFormat identifies the executable format of a file from its magic bytes,
+so that a damaged file of a known format can be reported as such.
+
+Parameters:
+  - path: Path to the file to check
+
+Returns:
+  - string: "ELF", "Mach-O" or "PE"
+  - error: An error if the file cannot be read or has an unknown format
+*/
+func detectFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open binary: %w", err)
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return "", fmt.Errorf("binary is too small to be an executable: %w", err)
+	}
+
+	switch {
+	case string(magic) == elf.ELFMAG:
+		return "ELF", nil
+	case string(magic[:2]) == "MZ":
+		return "PE", nil
+	}
+
+	switch binary.BigEndian.Uint32(magic) {
+	case macho.Magic32, macho.Magic64, macho.MagicFat,
+		0xcefaedfe, 0xcffaedfe: /* little-endian Mach-O magic read as big-endian */
+		return "Mach-O", nil
+	}
+
+	return "", fmt.Errorf("%s is not a recognized executable (ELF, Mach-O or PE)", path)
+}
+
+/*
+checkExtent reports a truncated binary when the file is smaller than the
+end of the last segment described by its headers.
+
+Parameters:
+  - size: Actual file size in bytes
+  - end: Offset of the last byte referenced by the headers
+
+Returns:
+  - error: An error if the file is truncated
+*/
+func checkExtent(size, end int64) error {
+	if end > size {
+		return fmt.Errorf("binary appears truncated: %d bytes on disk, headers require %d", size, end)
+	}
+	return nil
+}
+
+/*
+machoExtent returns the end offset of the last segment in a Mach-O file.
+For slices of a universal binary, base is the slice offset within the file.
+*/
+func machoExtent(f *macho.File, base int64) int64 {
+	var end int64
+	for _, load := range f.Loads {
+		if seg, ok := load.(*macho.Segment); ok {
+			end = max(end, base+int64(seg.Offset+seg.Filesz))
+		}
+	}
+	return end
+}
+
+/*
+elfArch maps an ELF machine type to its GOARCH name.
+*/
+func elfArch(f *elf.File) string {
+	switch f.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_PPC64:
+		if f.ByteOrder == binary.LittleEndian {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_S390:
+		return "s390x"
+	case elf.EM_RISCV:
+		return "riscv64"
+	case elf.EM_LOONGARCH:
+		return "loong64"
+	default:
+		return strings.ToLower(strings.TrimPrefix(f.Machine.String(), "EM_"))
+	}
+}
+
+/*
+machoArch maps a Mach-O CPU type to its GOARCH name.
+*/
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuArm:
+		return "arm"
+	case macho.CpuPpc64:
+		return "ppc64"
+	default:
+		return strings.ToLower(cpu.String())
+	}
+}
+
+/*
+peArch maps a PE machine type to its GOARCH name.
+*/
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		return "arm"
+	default:
+		return fmt.Sprintf("machine 0x%x", machine)
+	}
+}
@@ -0,0 +1,218 @@
+package installer
+
+import (
+	"bytes"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+/*
+machoHeader returns a 64-bit little-endian Mach-O header without load
+commands for the given CPU.
+*/
+func machoHeader(cpu macho.Cpu) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, macho.FileHeader{
+		Magic: macho.Magic64,
+		Cpu:   cpu,
+		Type:  macho.TypeExec,
+	})
+	/* The 64-bit header has a reserved word after the flags */
+	buf.Write(make([]byte, 4))
+	return buf.Bytes()
+}
+
+/*
+peHeader returns a PE file with a DOS header, signature and COFF header for
+the given machine type, without sections.
+*/
+func peHeader(machine uint16) []byte {
+	const peOffset = 0x80
+
+	data := make([]byte, peOffset)
+	copy(data, "MZ")
+	binary.LittleEndian.PutUint32(data[0x3c:], peOffset)
+
+	buf := bytes.NewBuffer(data)
+	buf.WriteString("PE\x00\x00")
+	binary.Write(buf, binary.LittleEndian, pe.FileHeader{Machine: machine})
+	return buf.Bytes()
+}
+
+/*
+hostELF returns the test binary itself, a complete ELF executable for the
+host architecture.
+*/
+func hostELF(t *testing.T) []byte {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("the test binary is only an ELF executable on Linux")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestValidateBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		goos    string
+		goarch  string
+		wantErr string
+	}{
+		{
+			name:   "complete ELF",
+			data:   hostELF,
+			goos:   "linux",
+			goarch: runtime.GOARCH,
+		},
+		{
+			name: "truncated ELF",
+			data: func(t *testing.T) []byte {
+				data := hostELF(t)
+				return data[:len(data)/2]
+			},
+			goos:    "linux",
+			goarch:  runtime.GOARCH,
+			wantErr: "truncated",
+		},
+		{
+			name: "ELF header only",
+			data: func(t *testing.T) []byte {
+				return hostELF(t)[:64]
+			},
+			goos:    "linux",
+			goarch:  runtime.GOARCH,
+			wantErr: "ELF",
+		},
+		{
+			name:    "ELF on macOS",
+			data:    hostELF,
+			goos:    "darwin",
+			goarch:  runtime.GOARCH,
+			wantErr: "expects Mach-O",
+		},
+		{
+			name:   "Mach-O for the host",
+			data:   func(*testing.T) []byte { return machoHeader(macho.CpuArm64) },
+			goos:   "darwin",
+			goarch: "arm64",
+		},
+		{
+			name:    "Mach-O for another architecture",
+			data:    func(*testing.T) []byte { return machoHeader(macho.CpuAmd64) },
+			goos:    "darwin",
+			goarch:  "arm64",
+			wantErr: "archive contains amd64 Mach-O, host is arm64",
+		},
+		{
+			name:   "PE for the host",
+			data:   func(*testing.T) []byte { return peHeader(pe.IMAGE_FILE_MACHINE_AMD64) },
+			goos:   "windows",
+			goarch: "amd64",
+		},
+		{
+			name:    "PE for another architecture",
+			data:    func(*testing.T) []byte { return peHeader(pe.IMAGE_FILE_MACHINE_ARM64) },
+			goos:    "windows",
+			goarch:  "amd64",
+			wantErr: "archive contains arm64 PE, host is amd64",
+		},
+		{
+			name:    "PE on Linux",
+			data:    func(*testing.T) []byte { return peHeader(pe.IMAGE_FILE_MACHINE_AMD64) },
+			goos:    "linux",
+			goarch:  "amd64",
+			wantErr: "expects ELF",
+		},
+		{
+			name:    "shell script",
+			data:    func(*testing.T) []byte { return []byte("#!/bin/sh\necho ollama\n") },
+			goos:    "linux",
+			goarch:  "amd64",
+			wantErr: "not a recognized executable",
+		},
+		{
+			name:    "HTML error page",
+			data:    func(*testing.T) []byte { return []byte("<html>Not Found</html>") },
+			goos:    "linux",
+			goarch:  "amd64",
+			wantErr: "not a recognized executable",
+		},
+		{
+			name:    "too small",
+			data:    func(*testing.T) []byte { return []byte("MZ") },
+			goos:    "windows",
+			goarch:  "amd64",
+			wantErr: "too small",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ollama")
+			if err := os.WriteFile(path, tt.data(t), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := validateBinary(path, tt.goos, tt.goarch)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("validateBinary() = %v, want nil", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("validateBinary() = nil, want error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("validateBinary() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"ELF", []byte("\x7fELF\x02\x01\x01"), "ELF"},
+		{"PE", []byte("MZ\x90\x00"), "PE"},
+		{"Mach-O 64-bit little-endian", []byte{0xcf, 0xfa, 0xed, 0xfe}, "Mach-O"},
+		{"Mach-O 32-bit big-endian", []byte{0xfe, 0xed, 0xfa, 0xce}, "Mach-O"},
+		{"universal Mach-O", []byte{0xca, 0xfe, 0xba, 0xbe}, "Mach-O"},
+		{"gzip archive", []byte{0x1f, 0x8b, 0x08, 0x00}, ""},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ollama")
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := detectFormat(path)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("detectFormat() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("detectFormat() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package installer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	/* VersionFileName is the per-project file that selects an Ollama version */
+	VersionFileName = ".ollama-version"
+
+	/* Name of the file in VersionsDir holding the version used outside projects */
+	defaultVersionFileName = "default"
+
+	/* Name of the installer's data directory */
+	dataDirName = "ollama-installer"
+)
+
+/*
+DataDir returns the directory the installer keeps its own data in:
+$XDG_DATA_HOME/ollama-installer (default ~/.local/share/ollama-installer),
+or AppData\Local\ollama-installer on Windows.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: The data directory
+*/
+func DataDir(homeDir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(homeDir, "AppData", "Local", dataDirName)
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, dataDirName)
+	}
+	return filepath.Join(homeDir, ".local", "share", dataDirName)
+}
+
+/*
+VersionsDir returns the directory holding one subdirectory per installed
+version, used when Options.Versioned is set.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: The versions directory
+*/
+func VersionsDir(homeDir string) string {
+	return filepath.Join(DataDir(homeDir), "versions")
+}
+
+/*
+VersionBinary returns where the binary of a versioned install lives.
+
+Parameters:
+  - homeDir: The user's home directory
+  - version: The release tag (e.g., "v0.5.7")
+
+Returns:
+  - string: Path of the binary
+*/
+func VersionBinary(homeDir, version string) string {
+	return filepath.Join(VersionsDir(homeDir), version, getPlatformConfig().binaryName)
+}
+
+/*
+FindVersionFile looks for a .ollama-version file in dir and each of its
+parent directories, returning the nearest one.
+
+Parameters:
+  - dir: The directory to start from (normally the working directory)
+
+Returns:
+  - string: The version in the file (empty if no file was found)
+  - string: Path of the file that was used
+  - error: Any error reading the file that was found
+*/
+func FindVersionFile(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		path := filepath.Join(dir, VersionFileName)
+		version, err := ReadVersionFile(path)
+		if err == nil {
+			return version, path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", path, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+/*
+ReadVersionFile reads a version file. The version is the first line that
+is neither blank nor a # comment; a missing leading "v" is added. Anything
+but a release tag is rejected, since the version selects a directory.
+
+Parameters:
+  - path: Path of the version file
+
+Returns:
+  - string: The release tag
+  - error: Any error reading the file, or if it names no release tag
+*/
+func ReadVersionFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			version := NormalizeVersion(line)
+			if err := ValidateVersion(version); err != nil {
+				return "", fmt.Errorf("invalid version in %s: %w", path, err)
+			}
+			return version, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return "", fmt.Errorf("%s does not name a version", path)
+}
+
+/*
+DefaultVersion returns the version used outside projects with a
+.ollama-version file: the one most recently installed with the shim.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: The release tag (empty if none is set)
+  - error: Any error reading the setting
+*/
+func DefaultVersion(homeDir string) (string, error) {
+	version, err := ReadVersionFile(filepath.Join(VersionsDir(homeDir), defaultVersionFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return version, err
+}
+
+/*
+SetDefaultVersion records the version used outside projects.
+
+Parameters:
+  - homeDir: The user's home directory
+  - version: The release tag
+
+Returns:
+  - error: Any error writing the setting
+*/
+func SetDefaultVersion(homeDir, version string) error {
+	if err := os.MkdirAll(VersionsDir(homeDir), executableMode); err != nil {
+		return fileError(fmt.Errorf("failed to create versions directory: %w", err), nil)
+	}
+
+	path := filepath.Join(VersionsDir(homeDir), defaultVersionFileName)
+	if err := os.WriteFile(path, []byte(version+"\n"), configFileMode); err != nil {
+		return fileError(fmt.Errorf("failed to write %s: %w", path, err), nil)
+	}
+	return nil
+}
+
+/*
+ShimScript returns the POSIX shell script installed as `ollama` in place of
+the binary. It hands its arguments to `ollama-installer shim`, which picks
+the version and runs it.
+
+Parameters:
+  - installerPath: Absolute path of the ollama-installer executable
+
+Returns:
+  - string: The script
+*/
+func ShimScript(installerPath string) string {
+	quoted := "'" + strings.ReplaceAll(installerPath, "'", `'\''`) + "'"
+	return "#!/bin/sh\n" +
+		"# Installed by ollama-installer. Runs the Ollama version named in the nearest\n" +
+		"# " + VersionFileName + " file, or the default version outside projects.\n" +
+		"exec " + quoted + " shim -- \"$@\"\n"
+}
+
+/*
+InstallShim writes the shim script as the ollama binary in binDir,
+replacing any binary or shim already there.
+
+Parameters:
+  - binDir: Directory on PATH to install the shim into
+  - installerPath: Absolute path of the ollama-installer executable
+
+Returns:
+  - string: Path of the installed shim
+  - error: Any error if the shim is unsupported or cannot be written
+*/
+func InstallShim(binDir, installerPath string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", errors.New("the version shim is not supported on Windows")
+	}
+
+	if err := os.MkdirAll(binDir, executableMode); err != nil {
+		return "", fileError(fmt.Errorf("failed to create bin directory: %w", err), nil)
+	}
+
+	shimPath := filepath.Join(binDir, getPlatformConfig().binaryName)
+	partialPath := shimPath + partialSuffix
+	if err := os.WriteFile(partialPath, []byte(ShimScript(installerPath)), executableMode); err != nil {
+		return "", fileError(fmt.Errorf("failed to write shim: %w", err), nil)
+	}
+	if err := os.Rename(partialPath, shimPath); err != nil {
+		os.Remove(partialPath)
+		return "", fileError(fmt.Errorf("failed to install shim: %w", err), nil)
+	}
+	return shimPath, nil
+}
@@ -0,0 +1,65 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "v0.5.7"},
+		{version: "v0.6.0-rc1"},
+		{version: "v10.20.30-rc.1"},
+		{version: "", wantErr: true},
+		{version: "0.5.7", wantErr: true},
+		{version: "v0.5", wantErr: true},
+		{version: "latest", wantErr: true},
+		{version: "v0.5.7/../../x", wantErr: true},
+		{version: "v../../../../tmp/x", wantErr: true},
+		{version: "v0.5.7-..", wantErr: true},
+		{version: `v0.5.7-rc1\x`, wantErr: true},
+		{version: "v0.5.7\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if err := ValidateVersion(tt.version); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVersion(%q) = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadVersionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "tag", content: "v0.5.7\n", want: "v0.5.7"},
+		{name: "without v", content: "0.5.7", want: "v0.5.7"},
+		{name: "comments and blank lines", content: "# pinned for CI\n\n  0.6.0-rc1  \n", want: "v0.6.0-rc1"},
+		{name: "empty", content: "# nothing\n", wantErr: true},
+		{name: "path traversal", content: "../../../../../../../../../../tmp/x\n", wantErr: true},
+		{name: "absolute path", content: "/tmp/x\n", wantErr: true},
+		{name: "not a version", content: "latest\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), VersionFileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadVersionFile(path)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ReadVersionFile() = %q, %v, want %q, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
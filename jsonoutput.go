@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"timberlea-upload-tool/installer"
+)
+
+/* Values accepted by --output */
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+/*
+installResult is the final JSON document written in --output json mode.
+*/
+type installResult struct {
+	Status        string   `json:"status"`
+	Version       string   `json:"version,omitempty"`
+	AssetURL      string   `json:"asset_url,omitempty"`
+	Checksum      string   `json:"sha256,omitempty"`
+	InstallPath   string   `json:"install_path,omitempty"`
+	ModifiedFiles []string `json:"modified_files"`
+	DurationMS    int64    `json:"duration_ms"`
+	ErrorCode     int      `json:"error_code"`
+	ErrorClass    string   `json:"error_class,omitempty"`
+	Error         string   `json:"error,omitempty"`
+}
+
+/*
+planResult is the JSON document written for --dry-run in --output json mode.
+*/
+type planResult struct {
+	Status      string `json:"status"`
+	Version     string `json:"version"`
+	AssetURL    string `json:"asset_url"`
+	SizeBytes   int64  `json:"size_bytes"`
+	Checksum    string `json:"sha256"`
+	InstallPath string `json:"install_path"`
+	Overwrite   bool   `json:"overwrite"`
+	PathFile    string `json:"path_file,omitempty"`
+	PathLine    string `json:"path_line,omitempty"`
+	PathPresent bool   `json:"path_present"`
+	PathUpdate  bool   `json:"path_update"`
+	PathManual  bool   `json:"path_manual"`
+	EnvScript   string `json:"env_script,omitempty"`
+}
+
+/*
+jsonEvent is the newline-delimited JSON form of an installer.Event.
+*/
+type jsonEvent struct {
+	Kind       installer.EventKind `json:"event"`
+	Time       time.Time           `json:"time"`
+	Message    string              `json:"message,omitempty"`
+	Version    string              `json:"version,omitempty"`
+	URL        string              `json:"url,omitempty"`
+	Path       string              `json:"path,omitempty"`
+	Checksum   string              `json:"sha256,omitempty"`
+	BytesDone  int64               `json:"bytes_done,omitempty"`
+	BytesTotal int64               `json:"bytes_total,omitempty"`
+	ElapsedMS  int64               `json:"elapsed_ms,omitempty"`
+	Diff       string              `json:"diff,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+/*
+JSONObserver writes machine-readable output instead of human text. It
+collects the details of the installation from events and writes a single
+installResult document when the installation finishes. When streaming is
+enabled, every event is also written as one JSON object per line before
+the final document.
+*/
+type JSONObserver struct {
+	enc    *json.Encoder
+	stream bool
+	start  time.Time
+	result installResult
+	mu     sync.Mutex
+}
+
+/*
+NewJSONObserver creates an observer that writes JSON to w.
+
+Parameters:
+  - w: Destination for the JSON output (normally stdout)
+  - stream: Whether to also write each event as newline-delimited JSON
+
+Returns:
+  - *JSONObserver: The JSON observer
+*/
+func NewJSONObserver(w io.Writer, stream bool) *JSONObserver {
+	return &JSONObserver{
+		enc:    json.NewEncoder(w),
+		stream: stream,
+		start:  time.Now(),
+		result: installResult{ModifiedFiles: []string{}},
+	}
+}
+
+/*
+OnEvent implements installer.Observer by recording the event and writing output.
+*/
+func (j *JSONObserver) OnEvent(e installer.Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stream {
+		j.enc.Encode(toJSONEvent(e))
+	}
+
+	switch e.Kind {
+	case installer.EventResolved:
+		j.result.Version = e.Version
+		j.result.AssetURL = e.URL
+	case installer.EventDownloadStarted:
+		j.result.AssetURL = e.URL
+	case installer.EventVerify:
+		if e.Checksum != "" {
+			j.result.Checksum = e.Checksum
+		}
+	case installer.EventInstall:
+		j.result.InstallPath = e.Path
+	case installer.EventPathUpdated:
+		if e.Path != "" && !slices.Contains(j.result.ModifiedFiles, e.Path) {
+			j.result.ModifiedFiles = append(j.result.ModifiedFiles, e.Path)
+		}
+	case installer.EventDone:
+		j.result.Status = "success"
+		j.result.InstallPath = e.Path
+		if e.Err != nil {
+			j.result.ErrorCode = exitCode(e.Err)
+			j.result.ErrorClass = errorClass(j.result.ErrorCode)
+			j.result.Error = e.Err.Error()
+		}
+		j.finish(e)
+	case installer.EventFailed:
+		j.result.Status = "failed"
+		j.result.ErrorCode = exitCode(e.Err)
+		j.result.ErrorClass = errorClass(j.result.ErrorCode)
+		if e.Err != nil {
+			j.result.Error = e.Err.Error()
+		}
+		j.finish(e)
+	}
+}
+
+/*
+finish writes the final result document.
+*/
+func (j *JSONObserver) finish(e installer.Event) {
+	if e.Version != "" {
+		j.result.Version = e.Version
+	}
+	j.result.DurationMS = e.Time.Sub(j.start).Milliseconds()
+	j.enc.Encode(j.result)
+}
+
+/*
+writeFailure writes the result document for an installation that failed
+before it started, e.g. because of invalid options or lockfile.
+
+Parameters:
+  - w: Destination for the document (normally stdout)
+  - code: The process exit status
+  - err: The error that stopped the installation
+*/
+func writeFailure(w io.Writer, code int, err error) {
+	json.NewEncoder(w).Encode(installResult{
+		Status:        "failed",
+		ModifiedFiles: []string{},
+		ErrorCode:     code,
+		ErrorClass:    errorClass(code),
+		Error:         err.Error(),
+	})
+}
+
+/*
+writePlan writes a dry-run installation plan as a JSON document.
+
+Parameters:
+  - w: Destination for the plan (normally stdout)
+  - plan: The plan returned by installer.Plan
+*/
+func writePlan(w io.Writer, plan installer.Plan) {
+	doc := planResult{
+		Status:      "dry_run",
+		Version:     plan.Version,
+		AssetURL:    plan.URL,
+		SizeBytes:   plan.Size,
+		Checksum:    plan.Checksum,
+		InstallPath: plan.InstallPath,
+		Overwrite:   plan.Overwrite,
+	}
+	if change := plan.PathChange; change != nil {
+		doc.PathFile = change.File
+		doc.PathLine = change.Line
+		doc.PathPresent = change.Present
+		doc.PathUpdate = change.Update
+		doc.PathManual = change.Manual
+		doc.EnvScript = change.Script
+	}
+	json.NewEncoder(w).Encode(doc)
+}
+
+/*
+toJSONEvent converts an installer.Event to its JSON form.
+*/
+func toJSONEvent(e installer.Event) jsonEvent {
+	je := jsonEvent{
+		Kind:       e.Kind,
+		Time:       e.Time,
+		Message:    e.Message,
+		Version:    e.Version,
+		URL:        e.URL,
+		Path:       e.Path,
+		Checksum:   e.Checksum,
+		BytesDone:  e.BytesDone,
+		BytesTotal: e.BytesTotal,
+		ElapsedMS:  e.Elapsed.Milliseconds(),
+		Diff:       e.Diff,
+	}
+	if e.Err != nil {
+		je.Error = e.Err.Error()
+	}
+	return je
+}
+
+/* Process exit statuses, documented in the README */
+const (
+	exitOK               = 0
+	exitFailure          = 1
+	exitUsage            = 2
+	exitNetwork          = 3
+	exitRateLimited      = 4
+	exitAssetNotFound    = 5
+	exitChecksumMismatch = 6
+	exitExtraction       = 7
+	exitPermission       = 8
+	exitPathUpdate       = 9
+	exitDiskFull         = 10
+	exitInterrupted      = 130
+)
+
+/*
+exitCode returns the process exit status for an installation error.
+More specific classes are checked first, since a rate limit response
+is also a network failure.
+
+Parameters:
+  - err: The error that stopped the installation (nil on success)
+
+Returns:
+  - int: exitOK on success, otherwise the status for the error's class
+*/
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, installer.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, installer.ErrAssetNotFound):
+		return exitAssetNotFound
+	case errors.Is(err, installer.ErrChecksumMismatch):
+		return exitChecksumMismatch
+	case errors.Is(err, installer.ErrPermission):
+		return exitPermission
+	case errors.Is(err, installer.ErrDiskFull):
+		return exitDiskFull
+	case errors.Is(err, installer.ErrExtraction):
+		return exitExtraction
+	case errors.Is(err, installer.ErrNetwork):
+		return exitNetwork
+	case errors.Is(err, installer.ErrPathUpdate):
+		return exitPathUpdate
+	}
+	return exitFailure
+}
+
+/*
+errorClass names the class of failure an exit status stands for, for the
+error_class field of the JSON result.
+
+Parameters:
+  - code: The process exit status
+
+Returns:
+  - string: The class name, or "" for success
+*/
+func errorClass(code int) string {
+	switch code {
+	case exitOK:
+		return ""
+	case exitUsage:
+		return "usage"
+	case exitNetwork:
+		return "network"
+	case exitRateLimited:
+		return "rate_limited"
+	case exitAssetNotFound:
+		return "asset_not_found"
+	case exitChecksumMismatch:
+		return "checksum_mismatch"
+	case exitExtraction:
+		return "extraction"
+	case exitPermission:
+		return "permission"
+	case exitPathUpdate:
+		return "path_update"
+	case exitDiskFull:
+		return "disk_full"
+	case exitInterrupted:
+		return "interrupted"
+	}
+	return "failure"
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"timberlea-upload-tool/installer"
+)
+
+/* decodeResult decodes the single JSON document written to out */
+func decodeResult(t *testing.T, out *bytes.Buffer) map[string]any {
+	t.Helper()
+	var doc map[string]any
+	dec := json.NewDecoder(out)
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("failed to decode %q: %v", out.String(), err)
+	}
+	if dec.More() {
+		t.Errorf("more than one document written: %q", out.String())
+	}
+	return doc
+}
+
+func TestJSONObserverFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantClass string
+	}{
+		{name: "network", err: fmt.Errorf("failed to download: %w", installer.ErrNetwork), wantCode: 3, wantClass: "network"},
+		{name: "rate limited", err: fmt.Errorf("%w: %w", installer.ErrRateLimited, installer.ErrNetwork), wantCode: 4, wantClass: "rate_limited"},
+		{name: "asset not found", err: fmt.Errorf("no asset: %w", installer.ErrAssetNotFound), wantCode: 5, wantClass: "asset_not_found"},
+		{name: "checksum mismatch", err: fmt.Errorf("bad download: %w", installer.ErrChecksumMismatch), wantCode: 6, wantClass: "checksum_mismatch"},
+		{name: "extraction", err: fmt.Errorf("bad archive: %w", installer.ErrExtraction), wantCode: 7, wantClass: "extraction"},
+		{name: "permission", err: fmt.Errorf("%w: %w", installer.ErrPermission, installer.ErrExtraction), wantCode: 8, wantClass: "permission"},
+		{name: "path update", err: fmt.Errorf("no shell file: %w", installer.ErrPathUpdate), wantCode: 9, wantClass: "path_update"},
+		{name: "disk full", err: fmt.Errorf("%w: %w", installer.ErrDiskFull, installer.ErrExtraction), wantCode: 10, wantClass: "disk_full"},
+		{name: "interrupted", err: fmt.Errorf("download: %w", context.Canceled), wantCode: 130, wantClass: "interrupted"},
+		{name: "other", err: errors.New("something else"), wantCode: 1, wantClass: "failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			obs := NewJSONObserver(&out, false)
+			obs.OnEvent(installer.Event{Kind: installer.EventResolved, Version: "v0.5.7", URL: "https://example.com/ollama.tgz"})
+			obs.OnEvent(installer.Event{Kind: installer.EventFailed, Time: time.Now(), Err: tt.err})
+
+			doc := decodeResult(t, &out)
+			if doc["status"] != "failed" || doc["error_code"] != float64(tt.wantCode) || doc["error_class"] != tt.wantClass || doc["error"] != tt.err.Error() {
+				t.Errorf("result = %v, want failed with code %d (%s)", doc, tt.wantCode, tt.wantClass)
+			}
+			if doc["version"] != "v0.5.7" || doc["asset_url"] != "https://example.com/ollama.tgz" {
+				t.Errorf("result = %v, want the resolved version and URL", doc)
+			}
+			if files, ok := doc["modified_files"].([]any); !ok || len(files) != 0 {
+				t.Errorf("modified_files = %v, want an empty list", doc["modified_files"])
+			}
+		})
+	}
+}
+
+func TestJSONObserverSuccess(t *testing.T) {
+	pathErr := fmt.Errorf("failed to update any shell configuration file: %w", installer.ErrPathUpdate)
+
+	tests := []struct {
+		name      string
+		events    []installer.Event
+		done      installer.Event
+		wantFiles []string
+		wantCode  int
+		wantClass string
+	}{
+		{
+			name: "modified files",
+			events: []installer.Event{
+				{Kind: installer.EventPathUpdated, Path: "/home/me/.config/ollama-installer/env"},
+				{Kind: installer.EventPathUpdated, Path: "/home/me/.bashrc"},
+				{Kind: installer.EventPathUpdated, Path: "/home/me/.bashrc"},
+				{Kind: installer.EventPathUpdated},
+			},
+			done:      installer.Event{Kind: installer.EventDone, Path: "/home/me/bin/ollama"},
+			wantFiles: []string{"/home/me/.config/ollama-installer/env", "/home/me/.bashrc"},
+		},
+		{
+			name:      "no files modified",
+			done:      installer.Event{Kind: installer.EventDone, Path: "/home/me/bin/ollama"},
+			wantFiles: []string{},
+		},
+		{
+			name:      "PATH update failed",
+			done:      installer.Event{Kind: installer.EventDone, Path: "/home/me/bin/ollama", Err: pathErr},
+			wantFiles: []string{},
+			wantCode:  9,
+			wantClass: "path_update",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			obs := NewJSONObserver(&out, false)
+			obs.OnEvent(installer.Event{Kind: installer.EventResolved, Version: "v0.5.7"})
+			obs.OnEvent(installer.Event{Kind: installer.EventVerify, Checksum: "abc123"})
+			obs.OnEvent(installer.Event{Kind: installer.EventInstall, Path: "/home/me/bin/ollama"})
+			for _, e := range tt.events {
+				obs.OnEvent(e)
+			}
+			tt.done.Time = time.Now()
+			obs.OnEvent(tt.done)
+
+			var result installResult
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != "success" || result.Version != "v0.5.7" || result.Checksum != "abc123" || result.InstallPath != "/home/me/bin/ollama" {
+				t.Errorf("result = %+v, want a successful install of v0.5.7", result)
+			}
+			if !slices.Equal(result.ModifiedFiles, tt.wantFiles) {
+				t.Errorf("modified_files = %q, want %q", result.ModifiedFiles, tt.wantFiles)
+			}
+			if result.ErrorCode != tt.wantCode || result.ErrorClass != tt.wantClass {
+				t.Errorf("error_code = %d, error_class = %q, want %d, %q", result.ErrorCode, result.ErrorClass, tt.wantCode, tt.wantClass)
+			}
+			if tt.done.Err != nil && result.Error != tt.done.Err.Error() {
+				t.Errorf("error = %q, want %q", result.Error, tt.done.Err.Error())
+			}
+			if doc := decodeResult(t, bytes.NewBuffer(out.Bytes())); doc["modified_files"] == nil {
+				t.Error("modified_files is null, want a list")
+			}
+		})
+	}
+}
+
+func TestJSONObserverStream(t *testing.T) {
+	var out bytes.Buffer
+	obs := NewJSONObserver(&out, true)
+	obs.OnEvent(installer.Event{Kind: installer.EventResolved, Version: "v0.5.7"})
+	obs.OnEvent(installer.Event{Kind: installer.EventDone, Time: time.Now(), Path: "/home/me/bin/ollama"})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want two events and the result: %q", len(lines), out.String())
+	}
+	var last installResult
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil || last.Status != "success" {
+		t.Errorf("last line = %s, want the result document", lines[2])
+	}
+}
+
+func TestWriteFailure(t *testing.T) {
+	var out bytes.Buffer
+	writeFailure(&out, exitUsage, errors.New("--shim cannot be combined with --root"))
+
+	want := `{"status":"failed","modified_files":[],"duration_ms":0,"error_code":2,"error_class":"usage","error":"--shim cannot be combined with --root"}` + "\n"
+	if out.String() != want {
+		t.Errorf("writeFailure() = %s, want %s", out.String(), want)
+	}
+}
+
+func TestWritePlan(t *testing.T) {
+	plan := installer.Plan{
+		Version:     "v0.5.7",
+		URL:         "https://example.com/v0.5.7/ollama-linux-amd64.tgz",
+		Size:        -1,
+		Checksum:    "abc123",
+		InstallPath: "/home/me/bin/ollama",
+		Overwrite:   true,
+	}
+
+	tests := []struct {
+		name   string
+		change *installer.PathChange
+		want   string
+	}{
+		{
+			name: "without a PATH change",
+			want: `{"status":"dry_run","version":"v0.5.7","asset_url":"https://example.com/v0.5.7/ollama-linux-amd64.tgz","size_bytes":-1,"sha256":"abc123","install_path":"/home/me/bin/ollama","overwrite":true,"path_present":false,"path_update":false,"path_manual":false}`,
+		},
+		{
+			name:   "with a PATH change",
+			change: &installer.PathChange{File: "/home/me/.bashrc", Line: ". env", Script: "/home/me/.config/ollama-installer/env", Update: true},
+			want:   `{"status":"dry_run","version":"v0.5.7","asset_url":"https://example.com/v0.5.7/ollama-linux-amd64.tgz","size_bytes":-1,"sha256":"abc123","install_path":"/home/me/bin/ollama","overwrite":true,"path_file":"/home/me/.bashrc","path_line":". env","path_present":false,"path_update":true,"path_manual":false,"env_script":"/home/me/.config/ollama-installer/env"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan.PathChange = tt.change
+			var out bytes.Buffer
+			writePlan(&out, plan)
+			if got := strings.TrimSpace(out.String()); got != tt.want {
+				t.Errorf("writePlan() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
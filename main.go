@@ -3,778 +3,517 @@ Package main provides an installer for the Ollama CLI tool.
 It downloads the latest version from GitHub releases and installs it
 to the user's ~/bin directory, automatically updating shell configuration
 to include the binary in PATH.
+
+The installation logic lives in the installer package; this command parses
+flags, chooses how output is presented and maps the result to an exit code.
 */
 package main
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"bufio"
-	"compress/gzip"
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"time"
-)
-
-const (
-	/* GitHub API configuration */
-	githubAPIURL = "https://api.github.com/repos/ollama/ollama/releases/latest"
-
-	/* File permissions */
-	executableMode = 0755
-	configFileMode = 0644
+	"syscall"
 
-	/* HTTP timeout */
-	httpTimeout = 30 * time.Second
-
-	/* Temporary directory name */
-	tempDirName = "ollama-extract"
+	"timberlea-upload-tool/installer"
 )
 
-/* Platform-specific configuration */
-type PlatformConfig struct {
-	downloadURLTemplate string
-	tempFileName        string
-	installPath         string
-	binaryName          string
-}
-
 /*
-GitHubRelease represents the structure of a GitHub release API response.
-It contains the tag name which corresponds to the version number.
+cliOptions holds the command-line settings that control presentation
+rather than installation.
 */
-type GitHubRelease struct {
-	/* TagName is the git tag associated with the release (e.g., "v0.1.20") */
-	TagName string `json:"tag_name"`
-}
+type cliOptions struct {
+	/* quiet hides the download progress display */
+	quiet bool
 
-/*
-getLatestOllamaVersion fetches the latest Ollama version from the GitHub API.
-It makes an HTTP GET request to the GitHub releases API and parses the response
-to extract the tag name of the latest release.
+	/* output selects human-readable text or JSON output */
+	output string
 
-Parameters:
-  - ctx: Context for request cancellation and timeout
+	/* jsonEvents streams every event as JSON while running in JSON mode */
+	jsonEvents bool
 
-Returns:
-  - string: The version tag (e.g., "v0.1.20")
-  - error: Any error that occurred during the API call or response parsing
-*/
-func getLatestOllamaVersion(ctx context.Context) (string, error) {
-	client := &http.Client{
-		Timeout: httpTimeout,
-	}
+	/* dryRun prints the installation plan instead of installing */
+	dryRun bool
 
-	req, err := http.NewRequestWithContext(ctx, "GET", githubAPIURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
+	/* replaceMirrors makes the first --mirror flag discard configured mirrors */
+	replaceMirrors bool
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch latest release: %w", err)
-	}
-	defer resp.Body.Close()
+	/* locked installs the version and checksum pinned in the lockfile */
+	locked bool
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
-	}
+	/* lockFile is the lockfile written by lock and read by --locked */
+	lockFile string
 
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
+	/* shim installs into a versioned directory behind the version shim */
+	shim bool
 
-	return release.TagName, nil
+	/* autoInstall lets the shim install versions that are missing */
+	autoInstall bool
+
+	/* noCI installs as on a workstation even when a CI system is detected */
+	noCI bool
+
+	/* rootUser names the user in the --root system's /etc/passwd to install for */
+	rootUser string
 }
 
 /*
-getPlatformConfig returns the platform-specific configuration based on the current OS.
-It determines the appropriate download URL template, file extensions, and paths
-for Windows and Linux platforms.
+main is the entry point of the Ollama installer.
+It parses the command-line options and runs the installation with console
+or JSON output. The installation process consists of:
+ 1. Fetching the latest Ollama version from GitHub
+ 2. Constructing the download URL on each configured mirror
+ 3. Installing Ollama to ~/bin/ollama and verifying that it runs
+ 4. Updating the user's shell configuration
 
-Returns:
-  - PlatformConfig: Configuration struct with platform-specific settings
+With --dry-run it stops after working out these steps and prints the plan.
+
+Other subcommands are `config` (persistent defaults, see runConfig), `lock`
+(write a lockfile, see runLock), `shim` (run by the version shim, see
+runShim), `asdf` (the asdf plugin contract, see runAsdf), `path` (remove
+the PATH block, see runPath), `env` (print the env script, see runEnv) and
+`service` (run `ollama serve` as a systemd user service, see runService).
+`install` may be given explicitly and is the default.
+
+The program exits with the status code from exitCode if any step fails
+(including a failed PATH update after an otherwise successful install),
+with status code 2 for invalid command-line options, and with status
+code 130 when interrupted.
 */
-func getPlatformConfig() PlatformConfig {
-	switch runtime.GOOS {
-	case "windows":
-		return PlatformConfig{
-			downloadURLTemplate: "https://github.com/ollama/ollama/releases/download/%s/ollama-windows-amd64.zip",
-			tempFileName:        "ollama.zip",
-			installPath:         "~/AppData/Local/Programs/Ollama/ollama.exe",
-			binaryName:          "ollama.exe",
-		}
-	case "linux":
-		return PlatformConfig{
-			downloadURLTemplate: "https://github.com/ollama/ollama/releases/download/%s/ollama-linux-amd64.tgz",
-			tempFileName:        "ollama.tgz",
-			installPath:         "~/bin/ollama",
-			binaryName:          "ollama",
-		}
-	case "darwin":
-		return PlatformConfig{
-			downloadURLTemplate: "https://github.com/ollama/ollama/releases/download/%s/ollama-darwin.zip",
-			tempFileName:        "ollama.zip",
-			installPath:         "~/bin/ollama",
-			binaryName:          "ollama",
-		}
-	default:
-		// Default to Linux
-		return PlatformConfig{
-			downloadURLTemplate: "https://github.com/ollama/ollama/releases/download/%s/ollama-linux-amd64.tgz",
-			tempFileName:        "ollama.tgz",
-			installPath:         "~/bin/ollama",
-			binaryName:          "ollama",
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			os.Exit(runConfig(args[1:]))
+		case "lock":
+			os.Exit(runLock(args[1:]))
+		case "shim":
+			os.Exit(runShim(args[1:]))
+		case "asdf":
+			os.Exit(runAsdf(args[1:]))
+		case "path":
+			os.Exit(runPath(args[1:]))
+		case "env":
+			os.Exit(runEnv(args[1:]))
+		case "service":
+			os.Exit(runService(args[1:]))
+		case "install":
+			args = args[1:]
 		}
 	}
+	os.Exit(runInstall(args))
 }
 
+/* Variables describing this session's directories, ignored with --root */
+var rootUnsetEnv = []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "ZDOTDIR"}
+
 /*
-getDownloadURL constructs the download URL for a specific Ollama version.
-It formats the GitHub releases download URL template with the provided version
-using platform-specific configuration.
+installFlags defines the install command's flags. Parsed values are
+stored in opts and cli.
 
 Parameters:
-  - version: The version tag (e.g., "v0.1.20")
+  - opts: Installer options to fill in
+  - cli: Presentation options to fill in
 
 Returns:
-  - string: The complete download URL for the current platform
+  - *flag.FlagSet: The flag set, ready to parse
 */
-func getDownloadURL(version string) string {
-	config := getPlatformConfig()
-	return fmt.Sprintf(config.downloadURLTemplate, version)
+func installFlags(opts *installer.Options, cli *cliOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+
+	flags.BoolVar(&opts.ServeCheck, "serve-check", false, "after installing, start ollama serve on a temporary port and check /api/version")
+	flags.IntVar(&opts.Connections, "connections", installer.DefaultConnections, "number of parallel connections for the download (1 disables chunking)")
+	flags.Func("mirror", "base URL, file path or \"github\" to download releases from (repeatable, tried fastest first)", func(value string) error {
+		mirror, err := installer.ParseMirror(value)
+		if err != nil {
+			return err
+		}
+		/* Mirrors given on the command line replace configured ones */
+		if cli.replaceMirrors {
+			opts.Mirrors = nil
+			cli.replaceMirrors = false
+		}
+		opts.Mirrors = append(opts.Mirrors, mirror)
+		return nil
+	})
+	flags.BoolVar(&opts.TrustMirrorChecksums, "trust-mirror-checksums", false, "use the mirror's sha256sum.txt if GitHub's cannot be fetched, e.g. without access to GitHub")
+	flags.Func("limit-rate", "maximum download speed in bytes per second, with optional K, M or G suffix (e.g. 5M)", func(value string) error {
+		rate, err := installer.ParseByteSize(value)
+		if err != nil {
+			return err
+		}
+		opts.LimitRate = rate
+		return nil
+	})
+	flags.Func("ollama-version", "install this release instead of the latest (e.g. v0.5.7)", func(value string) error {
+		opts.Version = installer.NormalizeVersion(value)
+		return nil
+	})
+	flags.StringVar(&opts.Token, "token", "", "GitHub token for API requests, to avoid rate limits")
+	flags.BoolVar(&cli.quiet, "quiet", false, "do not show download progress")
+	flags.StringVar(&cli.output, "output", outputText, "output format: text or json")
+	flags.BoolVar(&cli.jsonEvents, "json-events", false, "with --output json, also write each event as newline-delimited JSON")
+	flags.BoolVar(&cli.dryRun, "dry-run", false, "show what would be downloaded, installed and changed without doing it")
+	flags.BoolVar(&cli.locked, "locked", false, "install exactly the version and checksum pinned in the lockfile")
+	flags.StringVar(&cli.lockFile, "lockfile", installer.LockFileName, "lockfile written by lock and read by --locked")
+	flags.BoolVar(&cli.shim, "shim", false, "install into a per-version directory and put a shim on PATH that runs the version named by .ollama-version")
+	flags.BoolVar(&cli.autoInstall, "auto-install", false, "let the shim install versions named by .ollama-version that are missing")
+	flags.BoolVar(&opts.NoModifyPath, "no-modify-path", false, "do not edit shell configuration files; print the lines to add instead")
+	flags.Func("install-dir", "directory to install the ollama binary to, instead of ~/bin (added to PATH)", func(value string) error {
+		path, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		if opts.InstallDir, err = filepath.Abs(path); err != nil {
+			return err
+		}
+		return nil
+	})
+	flags.Func("profile", "shell configuration file to add the PATH line to, instead of the shell's startup files", func(value string) error {
+		path, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		opts.Profile = path
+		return nil
+	})
+	flags.BoolVar(&cli.noCI, "no-ci", false, "update shell configuration files even when running in CI")
+	flags.Func("export-file", "dotenv file to write the installed version and paths to (e.g. for a GitLab dotenv report)", func(value string) error {
+		path, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		opts.ExportFile = path
+		return nil
+	})
+	flags.Func("root", "root file system of a container image or chroot to install into, instead of this system", func(value string) error {
+		root, err := filepath.Abs(value)
+		if err != nil {
+			return err
+		}
+		opts.Root = root
+		return nil
+	})
+	flags.Func("home", "home directory to install into instead of $HOME; with --root, a path on the target system (e.g. /home/app)", func(value string) error {
+		if !filepath.IsAbs(value) {
+			return fmt.Errorf("%s is not an absolute path", value)
+		}
+		opts.HomeDir = filepath.Clean(value)
+		return nil
+	})
+	flags.StringVar(&cli.rootUser, "root-user", "", "with --root, install for this user, taking the home directory from the target system's /etc/passwd")
+	flags.Func("env", "OLLAMA_* variable to set in the env script, as NAME=VALUE (repeatable)", func(value string) error {
+		name, value, err := parseEnvSetting(value)
+		if err != nil {
+			return err
+		}
+		if opts.Env == nil {
+			opts.Env = map[string]string{}
+		}
+		opts.Env[name] = value
+		return nil
+	})
+
+	return flags
 }
 
 /*
-installOllama downloads and installs Ollama to the user's bin directory.
-It performs the complete installation process including:
-  - Downloading the binary archive from the provided URL
-  - Creating the ~/bin directory if it doesn't exist
-  - Extracting and installing the binary
-  - Making the binary executable
-  - Updating shell configuration files to include ~/bin in PATH
-  - Cleaning up temporary files
+expandHome turns a path given on the command line or in a configuration
+file into an absolute path, expanding a leading ~/ to the home directory.
 
 Parameters:
-  - ctx: Context for request cancellation and timeout
-  - url: The download URL for the Ollama binary archive
+  - path: The path
 
 Returns:
-  - error: Any error that occurred during the installation process
+  - string: The absolute path
+  - error: Any error finding the home or working directory
 */
-func installOllama(ctx context.Context, url string) error {
-	config := getPlatformConfig()
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	tempFile := filepath.Join(homeDir, config.tempFileName)
-
-	/* Ensure cleanup of temporary files */
-	defer func() {
-		os.Remove(tempFile)
-	}()
-
-	/* Download the file */
-	if err := downloadFile(ctx, url, tempFile); err != nil {
-		return fmt.Errorf("failed to download Ollama: %w", err)
-	}
-
-	/* For all platforms, use the extraction method */
-	tempDir := filepath.Join(homeDir, tempDirName)
-	
-	/* Determine the installation directory based on platform */
-	var binDir string
-	var finalPath string
-	if runtime.GOOS == "windows" {
-		binDir = filepath.Join(homeDir, "AppData", "Local", "Programs", "Ollama")
-		finalPath = filepath.Join(binDir, config.binaryName)
-	} else {
-		binDir = filepath.Join(homeDir, "bin")
-		finalPath = filepath.Join(binDir, config.binaryName)
-	}
-
-	/* Ensure cleanup of temporary directory */
-	defer func() {
-		os.RemoveAll(tempDir)
-	}()
-
-	/* Create the bin directory if it doesn't exist */
-	if err := os.MkdirAll(binDir, executableMode); err != nil {
-		return fmt.Errorf("failed to create bin directory: %w", err)
-	}
-
-	/* Extract and install the binary */
-	if err := extractAndInstall(tempFile, tempDir, finalPath, config); err != nil {
-		return fmt.Errorf("failed to extract and install: %w", err)
-	}
-
-	/* Update PATH in shell configuration (skip for Windows as it uses standard location) */
-	if runtime.GOOS != "windows" {
-		if err := updatePath(homeDir); err != nil {
-			fmt.Printf("Warning: Failed to update PATH: %v\n", err)
+func expandHome(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
 		}
-	} else {
-		fmt.Printf("Using standard Windows Ollama location (already in PATH)\n")
+		return filepath.Join(homeDir, rest), nil
 	}
-
-	fmt.Printf("Ollama installed successfully to %s\n", finalPath)
-	fmt.Printf("Please restart your terminal OR log out and log back in to use the new version\n")
-	return nil
+	return filepath.Abs(path)
 }
 
 /*
-downloadFile downloads a file from the given URL to the specified path.
-It uses Go's native HTTP client with progress indicators and follows redirects.
-The download progress is displayed to stdout.
+parseInstallArgs applies the configuration layers (see loadConfig) and then
+the command-line flags.
 
 Parameters:
-  - ctx: Context for request cancellation and timeout
-  - url: The URL to download from
-  - filePath: The local file path where the download should be saved
+  - args: Command-line arguments after the subcommand
 
 Returns:
-  - error: Any error that occurred during the download process
+  - installer.Options: The installer options
+  - cliOptions: The presentation options
+  - error: Any error loading or applying the configuration, or a
+    flagError for invalid command-line flags
 */
-func downloadFile(ctx context.Context, url, filePath string) error {
-	fmt.Printf("Downloading Ollama from %s...\n", url)
+func parseInstallArgs(args []string) (installer.Options, cliOptions, error) {
+	var opts installer.Options
+	var cli cliOptions
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: httpTimeout,
-	}
+	flags := installFlags(&opts, &cli)
 
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	cfg, err := loadConfig()
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return opts, cli, err
 	}
-
-	// Make the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+	if err := cfg.apply(flags); err != nil {
+		return opts, cli, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	// Create the output file
-	out, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer out.Close()
-
-	// Get file size for progress tracking
-	fileSize := resp.ContentLength
-
-	// Create a progress reader
-	progressReader := &ProgressReader{
-		Reader: resp.Body,
-		Total:  fileSize,
+	cli.replaceMirrors = len(opts.Mirrors) > 0
+	if err := flags.Parse(args); err != nil {
+		/* -h has printed the usage, as flag.ExitOnError would */
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		return opts, cli, flagError{err}
 	}
 
-	// Copy with progress
-	_, err = io.Copy(out, progressReader)
-	if err != nil {
-		return fmt.Errorf("failed to save file: %w", err)
-	}
+	return opts, cli, nil
+}
 
-	fmt.Println() // New line after progress
-	return nil
+/*
+flagError is an invalid command-line flag. The flag package has already
+reported it together with the usage, so it is not printed again.
+*/
+type flagError struct {
+	err error
 }
 
 /*
-ProgressReader wraps an io.Reader to provide download progress feedback.
+Error implements error.
+
+Returns:
+  - string: The flag package's message
 */
-type ProgressReader struct {
-	Reader    io.Reader
-	Total     int64
-	BytesRead int64
+func (e flagError) Error() string {
+	return e.err.Error()
 }
 
 /*
-Read implements io.Reader interface and tracks progress.
+printUsageError reports an error in the command line or configuration on
+stderr, unless the flag package has already done so.
+
+Parameters:
+  - err: The error to report
 */
-func (pr *ProgressReader) Read(p []byte) (int, error) {
-	n, err := pr.Reader.Read(p)
-	pr.BytesRead += int64(n)
-
-	if pr.Total > 0 {
-		percentage := float64(pr.BytesRead) / float64(pr.Total) * 100
-		fmt.Printf("\rProgress: %.1f%% (%d/%d bytes)", percentage, pr.BytesRead, pr.Total)
-	} else {
-		fmt.Printf("\rDownloaded: %d bytes", pr.BytesRead)
+func printUsageError(err error) {
+	if !errors.As(err, &flagError{}) {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 	}
-
-	return n, err
 }
 
 /*
-extractAndInstall extracts the downloaded archive and installs the binary.
-It performs the following steps:
-  - Creates a temporary extraction directory
-  - Extracts the archive (ZIP for Windows, TGZ for Linux) using Go native libraries
-  - Copies the extracted binary to the final installation path
-  - Sets executable permissions on the binary
+runInstall implements the install command, which is also the default when
+no subcommand is given. Defaults come from the configuration layers (see
+loadConfig) and are overridden by the command-line flags.
 
 Parameters:
-  - archivePath: Path to the downloaded archive
-  - tempDir: Temporary directory for extraction
-  - finalPath: Final installation path for the binary
-  - config: Platform-specific configuration
+  - args: Command-line arguments after the subcommand
 
 Returns:
-  - error: Any error that occurred during extraction or installation
+  - int: The process exit status
 */
-func extractAndInstall(archivePath, tempDir, finalPath string, config PlatformConfig) error {
-	/* Clean up and create temporary extraction directory */
-	if err := os.RemoveAll(tempDir); err != nil {
-		return fmt.Errorf("failed to remove existing temp directory: %w", err)
-	}
-
-	if err := os.MkdirAll(tempDir, executableMode); err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+func runInstall(args []string) int {
+	opts, cli, err := parseInstallArgs(args)
+
+	/*
+		Until the observer exists, failures are reported here: on stderr,
+		and with --output json also as a failed result document
+	*/
+	fail := func(code int, err error) int {
+		if cli.output == outputJSON {
+			writeFailure(os.Stdout, code, err)
+		} else {
+			printUsageError(err)
+		}
+		return code
 	}
-
-	fmt.Printf("Extracting Ollama binary...\n")
-
-	/* Extract based on file type */
-	var sourcePath string
-	var err error
-
-	if strings.HasSuffix(config.tempFileName, ".zip") {
-		sourcePath, err = extractZip(archivePath, tempDir, config.binaryName)
-	} else {
-		sourcePath, err = extractTarGz(archivePath, tempDir)
+	usage := func(format string, args ...any) int {
+		return fail(exitUsage, fmt.Errorf(format, args...))
 	}
 
 	if err != nil {
-		return fmt.Errorf("extraction failed: %w", err)
+		return fail(exitUsage, err)
 	}
 
-	/* Copy the extracted binary to the final location */
-	if err := copyFile(sourcePath, finalPath); err != nil {
-		return fmt.Errorf("failed to copy binary: %w", err)
+	if cli.locked {
+		if err := applyLock(&opts, cli.lockFile); err != nil {
+			return fail(exitCode(err), err)
+		}
 	}
 
-	/* Make it executable */
-	if err := os.Chmod(finalPath, executableMode); err != nil {
-		return fmt.Errorf("failed to make binary executable: %w", err)
+	if cli.shim && opts.InstallDir != "" {
+		return usage("--shim cannot be combined with --install-dir; versions are installed under the data directory")
 	}
 
-	return nil
-}
-
-/*
-extractZip extracts a ZIP archive and returns the path to the binary.
-This is used for Windows and macOS downloads.
-
-Parameters:
-  - archivePath: Path to the ZIP file
-  - tempDir: Directory to extract to
-  - binaryName: Name of the binary to find
-
-Returns:
-  - string: Path to the extracted binary
-  - error: Any error that occurred during extraction
-*/
-func extractZip(archivePath, tempDir, binaryName string) (string, error) {
-	reader, err := zip.OpenReader(archivePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open zip file: %w", err)
+	if cli.shim && opts.HomeDir != "" {
+		return usage("--shim cannot be combined with --home")
 	}
-	defer reader.Close()
-
-	var binaryPath string
-
-	for _, file := range reader.File {
-		/* Create the file path */
-		path := filepath.Join(tempDir, file.Name)
 
-		/* Ensure we don't extract outside of tempDir */
-		if !strings.HasPrefix(path, filepath.Clean(tempDir)+string(os.PathSeparator)) {
-			continue
+	if cli.rootUser != "" {
+		if opts.Root == "" || opts.HomeDir != "" {
+			return usage("--root-user requires --root and cannot be combined with --home")
 		}
-
-		if file.FileInfo().IsDir() {
-			os.MkdirAll(path, file.FileInfo().Mode())
-			continue
+		if opts.HomeDir, err = installer.RootUserHome(opts.Root, cli.rootUser); err != nil {
+			return fail(exitUsage, err)
 		}
+	}
 
-		/* Create parent directories */
-		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			return "", fmt.Errorf("failed to create directory: %w", err)
+	if opts.Root != "" {
+		if cli.shim {
+			return usage("--shim cannot be combined with --root")
 		}
-
-		/* Extract file */
-		fileReader, err := file.Open()
-		if err != nil {
-			return "", fmt.Errorf("failed to open file in zip: %w", err)
+		if opts.ServeCheck {
+			return usage("--serve-check cannot be combined with --root; the installed binary is not run")
 		}
 
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.FileInfo().Mode())
-		if err != nil {
-			fileReader.Close()
-			return "", fmt.Errorf("failed to create target file: %w", err)
-		}
-
-		_, err = io.Copy(targetFile, fileReader)
-		fileReader.Close()
-		targetFile.Close()
-
-		if err != nil {
-			return "", fmt.Errorf("failed to copy file: %w", err)
+		/* This session's locations mean nothing on the target system */
+		for _, name := range rootUnsetEnv {
+			os.Unsetenv(name)
 		}
+	}
 
-		/* Check if this is the binary we're looking for */
-		if filepath.Base(path) == binaryName {
-			binaryPath = path
+	if cli.shim {
+		opts.Versioned = true
+		if !cli.dryRun {
+			homeDir, err := os.UserHomeDir()
+			if err == nil {
+				opts.ShimTarget, err = installSelf(homeDir)
+			}
+			if err != nil {
+				return fail(exitFailure, err)
+			}
 		}
 	}
 
-	if binaryPath == "" {
-		return "", fmt.Errorf("binary %s not found in zip archive", binaryName)
+	/* In CI, PATH and outputs go to the files the CI system reads between steps */
+	if ci, ok := installer.DetectCI(); ok && !cli.noCI && opts.Root == "" {
+		opts.CI = &ci
 	}
 
-	return binaryPath, nil
-}
-
-/*
-extractTarGz extracts a tar.gz archive and returns the path to the binary.
-This is used for Linux downloads. Uses pure Go implementation.
-
-Parameters:
-  - archivePath: Path to the tar.gz file
-  - tempDir: Directory to extract to
-
-Returns:
-  - string: Path to the extracted binary
-  - error: Any error that occurred during extraction
-*/
-func extractTarGz(archivePath, tempDir string) (string, error) {
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to open tar.gz file: %w", err)
+	switch cli.output {
+	case outputText:
+		opts.Observer = NewConsoleObserver(os.Stdout, cli.quiet)
+	case outputJSON:
+		opts.Observer = NewJSONObserver(os.Stdout, cli.jsonEvents)
+	default:
+		return usage("invalid value %q for -output: must be %s or %s", cli.output, outputText, outputJSON)
 	}
-	defer file.Close()
 
-	gzReader, err := gzip.NewReader(file)
+	inst, err := installer.New(opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to create gzip reader: %w", err)
+		return fail(exitCode(err), err)
 	}
-	defer gzReader.Close()
 
-	tarReader := tar.NewReader(gzReader)
-	var binaryPath string
+	/*
+		Ctrl-C or SIGTERM cancels the installation so it can clean up; once
+		cancelled, the default handling is restored so a second signal exits
+		immediately.
+	*/
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
 
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
+	if cli.dryRun {
+		plan, err := inst.Plan(ctx)
 		if err != nil {
-			return "", fmt.Errorf("failed to read tar entry: %w", err)
-		}
-
-		// Create the file path
-		path := filepath.Join(tempDir, header.Name)
-
-		// Ensure we don't extract outside of tempDir (security check)
-		if !strings.HasPrefix(path, filepath.Clean(tempDir)+string(os.PathSeparator)) {
-			continue
+			return exitCode(err)
 		}
-
-		switch header.Typeflag {
-		case tar.TypeDir:
-			// Create directory
-			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
-				return "", fmt.Errorf("failed to create directory %s: %w", path, err)
-			}
-
-		case tar.TypeReg:
-			// Create parent directories
-			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-				return "", fmt.Errorf("failed to create parent directory: %w", err)
-			}
-
-			// Create and write file
-			outFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return "", fmt.Errorf("failed to create file %s: %w", path, err)
-			}
-
-			_, err = io.Copy(outFile, tarReader)
-			outFile.Close()
-
-			if err != nil {
-				return "", fmt.Errorf("failed to write file %s: %w", path, err)
-			}
-
-			// Check if this is the binary we're looking for
-			if strings.HasSuffix(path, "/bin/ollama") || filepath.Base(path) == "ollama" {
-				binaryPath = path
-			}
+		if cli.output == outputJSON {
+			writePlan(os.Stdout, plan)
+		} else {
+			printPlan(os.Stdout, plan)
 		}
+		return exitOK
 	}
 
-	if binaryPath == "" {
-		return "", fmt.Errorf("ollama binary not found in tar.gz archive")
+	result, err := inst.Install(ctx)
+	if err == nil {
+		err = result.PathErr
 	}
-
-	return binaryPath, nil
+	return exitCode(err)
 }
 
 /*
-copyFile copies a file from source to destination.
+runLock implements the lock command. It resolves the version (the latest
+release unless pinned) and writes the lockfile with the checksum of every
+platform's asset. It accepts the same flags as install.
 
 Parameters:
-  - src: Source file path
-  - dst: Destination file path
+  - args: Command-line arguments after `lock`
 
 Returns:
-  - error: Any error that occurred during copying
+  - int: The process exit status
 */
-func copyFile(src, dst string) error {
-	sourceFile, err := os.Open(src)
+func runLock(args []string) int {
+	opts, cli, err := parseInstallArgs(args)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		printUsageError(err)
+		return exitUsage
 	}
-	defer sourceFile.Close()
+	opts.Observer = NewConsoleObserver(os.Stdout, true)
 
-	destFile, err := os.Create(dst)
+	inst, err := installer.New(opts)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitCode(err)
 	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	if err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
-	}
-
-	return nil
-}
-
-/*
-updatePath adds the ~/bin directory to PATH based on the operating system.
-For Windows, it updates the user PATH environment variable using PowerShell.
-For Unix systems, it updates shell configuration files.
 
-Parameters:
-  - homeDir: The user's home directory path
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-Returns:
-  - error: Any error that occurred during the PATH update process
-*/
-func updatePath(homeDir string) error {
-	if runtime.GOOS == "windows" {
-		return updateWindowsPath(homeDir)
+	lock, err := inst.Lock(ctx)
+	if err == nil {
+		err = lock.Write(cli.lockFile)
 	}
-	return updateUnixPath(homeDir)
-}
-
-/*
-updateWindowsPath adds the ~/bin directory to the Windows user PATH environment variable.
-It uses PowerShell to safely update the PATH without truncation issues.
-
-Parameters:
-  - homeDir: The user's home directory path
-
-Returns:
-  - error: Any error that occurred during the PATH update process
-*/
-func updateWindowsPath(homeDir string) error {
-	binDir := filepath.Join(homeDir, "bin")
-
-	// Check if already in PATH
-	currentPath := os.Getenv("PATH")
-	if strings.Contains(currentPath, binDir) {
-		fmt.Printf("PATH already contains %s\n", binDir)
-		return nil
-	}
-
-	// Use PowerShell to safely update the user PATH environment variable
-	psScript := fmt.Sprintf(`
-$currentPath = [Environment]::GetEnvironmentVariable('PATH', 'User')
-$newPath = '%s'
-if ($currentPath -notlike "*$newPath*") {
-    if ($currentPath) {
-        $updatedPath = $newPath + ';' + $currentPath
-    } else {
-        $updatedPath = $newPath
-    }
-    [Environment]::SetEnvironmentVariable('PATH', $updatedPath, 'User')
-    Write-Host "Successfully added $newPath to user PATH"
-} else {
-    Write-Host "PATH already contains $newPath"
-}`, binDir)
-
-	// Execute the PowerShell script
-	execCmd := exec.Command("powershell", "-Command", psScript)
-	output, err := execCmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("failed to update Windows PATH: %v, output: %s", err, string(output))
-	}
-
-	fmt.Printf("PATH update output: %s\n", string(output))
-	fmt.Printf("Successfully updated Windows user PATH to include %s\n", binDir)
-	fmt.Printf("Note: You may need to restart your terminal for the PATH change to take effect\n")
-	return nil
-}
-
-/*
-updateUnixPath adds the ~/bin directory to PATH in shell configuration files.
-It checks common shell configuration files (.zshrc, .bash_profile, .bashrc, .profile)
-in order of preference and adds the PATH export statement if not already present.
-
-Parameters:
-  - homeDir: The user's home directory path
-
-Returns:
-  - error: Any error that occurred during the PATH update process
-*/
-func updateUnixPath(homeDir string) error {
-	pathExport := `export PATH="$HOME/bin:$PATH"`
-
-	/* List of shell configuration files to update (in order of preference) */
-	configFiles := []string{".zshrc", ".bash_profile", ".bashrc", ".profile"}
-
-	/* Check if PATH export already exists in any file */
-	for _, configFile := range configFiles {
-		configPath := filepath.Join(homeDir, configFile)
-		if pathAlreadyExists(configPath, pathExport) {
-			return nil /* Already exists */
-		}
+		fmt.Fprintf(os.Stderr, "Failed to write lockfile: %v\n", err)
+		return exitCode(err)
 	}
 
-	/* Try to update each configuration file in order of preference */
-	for _, configFile := range configFiles {
-		configPath := filepath.Join(homeDir, configFile)
-
-		/* Skip .zshrc if it doesn't exist (only update existing files) */
-		if configFile == ".zshrc" && !fileExists(configPath) {
-			continue
-		}
-
-		if err := appendToFile(configPath, pathExport); err == nil {
-			fmt.Printf("Updated %s with PATH export\n", configFile)
-			return nil
-		}
-	}
-
-	return fmt.Errorf("failed to update any shell configuration file")
+	fmt.Printf("Locked Ollama %s for %d platforms in %s\n", lock.Version, len(lock.Platforms), cli.lockFile)
+	return exitOK
 }
 
 /*
-pathAlreadyExists checks if the PATH export already exists in the given file.
-It reads the file line by line and searches for the PATH export statement.
+applyLock pins opts to the version and checksum in a lockfile, so the
+download fails with a checksum mismatch if the fetched artifact differs.
 
 Parameters:
-  - filePath: Path to the shell configuration file to check
-  - pathExport: The PATH export statement to search for
+  - opts: Installer options to update
+  - path: Path of the lockfile
 
 Returns:
-  - bool: true if the PATH export already exists, false otherwise
+  - error: Any error reading the lockfile, a conflicting --ollama-version,
+    or a lockfile without the right asset for this platform
 */
-func pathAlreadyExists(filePath, pathExport string) bool {
-	file, err := os.Open(filePath)
+func applyLock(opts *installer.Options, path string) error {
+	lock, err := installer.ReadLock(path)
 	if err != nil {
-		return false
+		return err
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		if strings.Contains(scanner.Text(), pathExport) {
-			return true
-		}
+	if opts.Version != "" && opts.Version != lock.Version {
+		return fmt.Errorf("version %s conflicts with %s locked in %s", opts.Version, lock.Version, path)
 	}
-	return false
-}
 
-/*
-fileExists checks if a file exists at the given path.
-It uses os.Stat to check for file existence and properly handles
-the case where the file doesn't exist (os.IsNotExist).
-
-Parameters:
-  - filename: Path to the file to check
-
-Returns:
-  - bool: true if the file exists, false otherwise
-*/
-func fileExists(filename string) bool {
-	_, err := os.Stat(filename)
-	return !os.IsNotExist(err)
-}
-
-/*
-appendToFile appends content to a file, creating it if it doesn't exist.
-The file is opened in append mode with create flag, and the content is
-written with newlines before and after for proper formatting.
-
-Parameters:
-  - filePath: Path to the file to append to
-  - content: Content to append to the file
-
-Returns:
-  - error: Any error that occurred during file operations
-*/
-func appendToFile(filePath, content string) error {
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, configFileMode)
+	asset, err := lock.Current()
 	if err != nil {
-		return fmt.Errorf("failed to open %s: %w", filePath, err)
-	}
-	defer file.Close()
-
-	if _, err := file.WriteString("\n" + content + "\n"); err != nil {
-		return fmt.Errorf("failed to write to %s: %w", filePath, err)
+		return fmt.Errorf("%s: %w; run `ollama-installer lock` to update it", path, err)
 	}
 
+	opts.Version = lock.Version
+	opts.Checksum = asset.SHA256
 	return nil
 }
-
-/*
-main is the entry point of the Ollama installer.
-It orchestrates the entire installation process by:
- 1. Fetching the latest Ollama version from GitHub
- 2. Constructing the download URL
- 3. Installing Ollama to ~/bin/ollama
- 4. Updating the user's shell configuration
-
-The program exits with status code 1 if any step fails.
-*/
-func main() {
-	ctx := context.Background()
-
-	fmt.Printf("Detected platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
-
-	version, err := getLatestOllamaVersion(ctx)
-	if err != nil {
-		fmt.Printf("Error getting latest version: %v\n", err)
-		os.Exit(1)
-	}
-
-	url := getDownloadURL(version)
-	fmt.Printf("Latest Ollama version: %s\n", version)
-	fmt.Printf("Download URL: %s\n", url)
-
-	if err := installOllama(ctx, url); err != nil {
-		fmt.Printf("Installation failed: %v\n", err)
-		os.Exit(1)
-	}
-}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"timberlea-upload-tool/installer"
+)
+
+/*
+runPath implements the path command. `path remove` deletes the block the
+installer added to shell configuration files, backing each file up first;
+running it again changes nothing. A file given with --profile, or
+configured with `config set profile`, is cleaned up as well.
+
+Parameters:
+  - args: Arguments after `path`
+
+Returns:
+  - int: The process exit status
+*/
+func runPath(args []string) int {
+	if len(args) == 0 || args[0] != "remove" {
+		fmt.Fprintln(os.Stderr, "usage: ollama-installer path remove [--profile FILE]")
+		return exitUsage
+	}
+
+	/* Only --profile applies; it defaults to the configured profile */
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	var profile string
+	flags := flag.NewFlagSet("path remove", flag.ExitOnError)
+	flags.Func("profile", "shell configuration file to remove the PATH block from, as well as the shell's startup files", func(value string) error {
+		path, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		profile = path
+		return nil
+	})
+	if setting, ok := cfg["profile"]; ok && len(setting.values) > 0 {
+		value := setting.values[len(setting.values)-1]
+		if err := flags.Set("profile", value); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid value %q for profile in %s: %v\n", value, setting.source, err)
+			return exitUsage
+		}
+	}
+	flags.Parse(args[1:])
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: ollama-installer path remove [--profile FILE]")
+		return exitUsage
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get home directory: %v\n", err)
+		return exitFailure
+	}
+
+	updater := &installer.ShellPathUpdater{HomeDir: homeDir, Profile: profile, Observer: NewConsoleObserver(os.Stdout, false)}
+	changed, err := updater.RemovePath(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to remove the PATH block: %v\n", err)
+		return exitCode(err)
+	}
+	if len(changed) == 0 {
+		fmt.Println("No ollama-installer PATH block found")
+	}
+	return exitOK
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"timberlea-upload-tool/installer"
+)
+
+/* Usage of the service command */
+const serviceUsage = "usage: ollama-installer service install [--host ADDR] [--models DIR] [--env NAME=VALUE]... | start | stop | status | uninstall"
+
+/*
+runService implements the service command, which runs `ollama serve` as a
+systemd user service:
+
+	service install    write the unit, then enable and start it
+	service start      start the service
+	service stop       stop the service
+	service status     show the service's status
+	service uninstall  stop the service and remove the unit
+
+The service's OLLAMA_* settings come from the `env` configuration setting,
+overridden by the flags given to `service install`.
+
+Parameters:
+  - args: Arguments after `service`
+
+Returns:
+  - int: The process exit status
+*/
+func runService(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return exitUsage
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get home directory: %v\n", err)
+		return exitFailure
+	}
+	manager := &installer.ServiceManager{HomeDir: homeDir, Observer: NewConsoleObserver(os.Stdout, true)}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	command, args := args[0], args[1:]
+	if command != "install" && len(args) > 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return exitUsage
+	}
+
+	switch command {
+	case "install":
+		return serviceInstall(ctx, manager, args)
+	case "start":
+		err = manager.Start(ctx)
+	case "stop":
+		err = manager.Stop(ctx)
+	case "status":
+		var running bool
+		running, err = manager.Status(ctx, os.Stdout)
+		if err == nil && !running {
+			return exitFailure
+		}
+	case "uninstall":
+		var installed bool
+		installed, err = manager.Uninstall(ctx)
+		if err == nil && !installed {
+			fmt.Println("The Ollama service is not installed")
+		}
+	default:
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return exitUsage
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+/*
+serviceInstall implements `service install`.
+
+Parameters:
+  - ctx: Context for cancellation
+  - manager: The service manager
+  - args: Arguments after `service install`
+
+Returns:
+  - int: The process exit status
+*/
+func serviceInstall(ctx context.Context, manager *installer.ServiceManager, args []string) int {
+	/* Start from the install directory and env settings in the configuration */
+	opts, _, err := parseInstallArgs(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitUsage
+	}
+	binDir := opts.InstallDir
+	if binDir == "" {
+		binDir = installer.BinDir(manager.HomeDir)
+	}
+	cfg := installer.ServiceConfig{
+		BinaryPath: filepath.Join(binDir, "ollama"),
+		Env:        opts.Env,
+	}
+	if cfg.Env == nil {
+		cfg.Env = map[string]string{}
+	}
+
+	flags := flag.NewFlagSet("service install", flag.ExitOnError)
+	flags.Func("binary", "ollama binary the service runs (default ollama in the configured install-dir or ~/bin)", func(value string) error {
+		path, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		cfg.BinaryPath = path
+		return nil
+	})
+	flags.Func("host", "address the server listens on (sets OLLAMA_HOST, e.g. 127.0.0.1:11434)", func(value string) error {
+		cfg.Env["OLLAMA_HOST"] = value
+		return nil
+	})
+	flags.Func("models", "directory the server keeps models in (sets OLLAMA_MODELS)", func(value string) error {
+		dir, err := expandHome(value)
+		if err != nil {
+			return err
+		}
+		cfg.Env["OLLAMA_MODELS"] = dir
+		return nil
+	})
+	flags.Func("env", "OLLAMA_* variable to set for the server, as NAME=VALUE (repeatable)", func(value string) error {
+		name, value, err := parseEnvSetting(value)
+		if err != nil {
+			return err
+		}
+		cfg.Env[name] = value
+		return nil
+	})
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, serviceUsage)
+		return exitUsage
+	}
+
+	if _, err := os.Stat(cfg.BinaryPath); err != nil {
+		fmt.Fprintf(os.Stderr, "%s is not installed; install Ollama first or pass --binary\n", cfg.BinaryPath)
+		return exitFailure
+	}
+
+	if _, err := manager.Install(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to install the service: %v\n", err)
+		return exitCode(err)
+	}
+	return exitOK
+}
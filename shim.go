@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"timberlea-upload-tool/installer"
+)
+
+/*
+runShim implements the shim command run by the `ollama` shim script. It
+selects a version from the nearest .ollama-version file (or the default
+version), installs it first if it is missing and auto-install is enabled,
+and then replaces itself with that version's binary.
+
+Parameters:
+  - args: Arguments after `shim`; everything after `--` is passed to ollama
+
+Returns:
+  - int: The process exit status if ollama could not be started
+*/
+func runShim(args []string) int {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ollama shim: failed to get home directory: %v\n", err)
+		return exitFailure
+	}
+
+	version, source, err := shimVersion(homeDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ollama shim: %v\n", err)
+		return exitFailure
+	}
+	if version == "" {
+		fmt.Fprintf(os.Stderr, "ollama shim: no Ollama version selected; add a %s file or run `ollama-installer install --shim`\n", installer.VersionFileName)
+		return exitFailure
+	}
+
+	binary := installer.VersionBinary(homeDir, version)
+	if _, err := os.Stat(binary); err != nil {
+		if code := shimInstall(version, source); code != exitOK {
+			return code
+		}
+	}
+
+	err = execBinary(binary, args)
+	fmt.Fprintf(os.Stderr, "ollama shim: failed to run %s: %v\n", binary, err)
+	return exitFailure
+}
+
+/*
+shimVersion picks the version the shim runs: the nearest .ollama-version
+file above the working directory, otherwise the default version.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: The release tag (empty if none is selected)
+  - string: Where the version came from, for messages
+  - error: Any error reading a version file
+*/
+func shimVersion(homeDir string) (string, string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	version, path, err := installer.FindVersionFile(cwd)
+	if err != nil || version != "" {
+		return version, path, err
+	}
+
+	version, err = installer.DefaultVersion(homeDir)
+	return version, "the default version", err
+}
+
+/*
+shimInstall installs a missing version for the shim when auto-install is
+enabled. Output goes to stderr so that ollama's own output stays clean.
+
+Parameters:
+  - version: The release tag to install
+  - source: Where the version came from, for messages
+
+Returns:
+  - int: exitOK once installed, otherwise the process exit status
+*/
+func shimInstall(version, source string) int {
+	opts, cli, err := parseInstallArgs(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ollama shim: %v\n", err)
+		return exitUsage
+	}
+	if !cli.autoInstall {
+		fmt.Fprintf(os.Stderr, "ollama shim: Ollama %s (from %s) is not installed; run `ollama-installer install --shim --ollama-version %s`, or enable auto-install with `ollama-installer config set auto-install true`\n", version, source, version)
+		return exitFailure
+	}
+
+	opts.Version = version
+	opts.Versioned = true
+	/* A configured install-dir is for plain installs; versions live in the data directory */
+	opts.InstallDir = ""
+	opts.Observer = NewConsoleObserver(os.Stderr, cli.quiet)
+
+	inst, err := installer.New(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ollama shim: %v\n", err)
+		return exitCode(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if _, err := inst.Install(ctx); err != nil {
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+/*
+installSelf copies the running ollama-installer executable into the data
+directory, so the shim keeps working if the downloaded installer is moved
+or deleted.
+
+Parameters:
+  - homeDir: The user's home directory
+
+Returns:
+  - string: Path of the copy the shim should run
+  - error: Any error if the shim is unsupported or the copy fails
+*/
+func installSelf(homeDir string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return "", fmt.Errorf("the version shim is not supported on Windows")
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate ollama-installer: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+
+	target := filepath.Join(installer.DataDir(homeDir), "ollama-installer")
+	if self == target {
+		return target, nil
+	}
+
+	data, err := os.ReadFile(self)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", self, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+
+	/* Write beside the target and rename, since the old copy may be running */
+	partial := target + ".partial"
+	if err := os.WriteFile(partial, data, 0755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", partial, err)
+	}
+	if err := os.Rename(partial, target); err != nil {
+		os.Remove(partial)
+		return "", fmt.Errorf("failed to install %s: %w", target, err)
+	}
+	return target, nil
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+/*
+execBinary replaces this process with binary, so signals and the exit
+status belong to ollama.
+
+Parameters:
+  - binary: Path of the ollama binary
+  - args: Arguments passed to ollama
+
+Returns:
+  - error: The error if binary could not be started (it never returns otherwise)
+*/
+func execBinary(binary string, args []string) error {
+	return syscall.Exec(binary, append([]string{binary}, args...), os.Environ())
+}
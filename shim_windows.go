@@ -0,0 +1,20 @@
+//go:build windows
+
+package main
+
+import "errors"
+
+/*
+execBinary would replace this process with binary, which Windows cannot
+do, so the version shim is not supported there.
+
+Parameters:
+  - binary: Path of the ollama binary
+  - args: Arguments passed to ollama
+
+Returns:
+  - error: Always an error explaining that the shim is unsupported
+*/
+func execBinary(binary string, args []string) error {
+	return errors.New("the version shim is not supported on Windows")
+}